@@ -3,7 +3,6 @@ package commands
 import (
 	"fmt"
 	"github.com/philippeckel/pair/internal/config"
-	"github.com/philippeckel/pair/internal/gittemplate"
 	"github.com/spf13/cobra"
 	"strings"
 )
@@ -19,14 +18,18 @@ func addCoAuthor(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get git user info: %w", err)
 	}
 
-	// Get current template
-	templatePath, err := gittemplate.GetCurrentTemplate()
+	scope, err := currentScope()
+	if err != nil {
+		return err
+	}
+
+	trailerKey, err := trailerKeyFlag(cmd)
 	if err != nil {
 		return err
 	}
 
 	// Get active co-authors
-	activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath)
+	_, activeCoAuthors, err := loadActiveCoAuthors(scope)
 	if err != nil {
 		return err
 	}
@@ -35,50 +38,68 @@ func addCoAuthor(cmd *cobra.Command, args []string) error {
 	added := false
 	// Track warnings to display at the end
 	var warnings []string
+	report := newChangeReport()
+
+	matcher := newMatcher()
 
 	// Process each co-author identifier provided in args
 	for _, identifier := range args {
-		coAuthor, _, err := findCoAuthorByAliasOrIndex(identifier)
+		coAuthors, err := resolveCoAuthors(identifier)
 		if err != nil {
 			// Return error for non-existent co-authors
 			return fmt.Errorf("error with '%s': %w", identifier, err)
 		}
 
-		// Check if attempting to add yourself as co-author
-		if strings.EqualFold(coAuthor.Email, userEmail) || strings.EqualFold(coAuthor.Name, userName) {
-			warnings = append(warnings, fmt.Sprintf("Cannot add yourself as a co-author: %s <%s>", coAuthor.Name, coAuthor.Email))
-			continue
-		}
+		for _, coAuthor := range coAuthors {
+			coAuthor.TrailerKey = trailerKey
 
-		// Check if co-author is already active
-		alreadyActive := false
-		for _, active := range activeCoAuthors {
-			if active.Email == coAuthor.Email {
-				alreadyActive = true
-				warnings = append(warnings, fmt.Sprintf("Co-author already active: %s <%s>", coAuthor.Name, coAuthor.Email))
-				break
+			// Check if attempting to add yourself as co-author, other trailers
+			// such as Signed-off-by may well credit yourself
+			if coAuthor.IsCoAuthor() && (matcher.Same(coAuthor.Email, userEmail) || strings.EqualFold(coAuthor.Name, userName)) {
+				warnings = append(warnings, fmt.Sprintf("Cannot add yourself as a co-author: %s <%s>", coAuthor.Name, coAuthor.Email))
+				report.skipped(coAuthor, false, "yourself")
+				continue
 			}
-		}
 
-		// Add co-author if not already active
-		if !alreadyActive {
-			activeCoAuthors = append(activeCoAuthors, coAuthor)
-			fmt.Printf("Adding co-author: %s <%s>\n", coAuthor.Name, coAuthor.Email)
-			added = true
+			// Check if co-author is already active with the same trailer
+			alreadyActive := false
+			for _, active := range activeCoAuthors {
+				if sameCredit(matcher, active, coAuthor) {
+					alreadyActive = true
+					warnings = append(warnings, fmt.Sprintf("Co-author already active: %s <%s>%s", coAuthor.Name, coAuthor.Email, trailerSuffix(coAuthor)))
+					report.skipped(coAuthor, true, "already active")
+					break
+				}
+			}
+
+			// Add co-author if not already active
+			if !alreadyActive {
+				activeCoAuthors = append(activeCoAuthors, coAuthor)
+				notef("Adding co-author: %s <%s>%s\n", coAuthor.Name, coAuthor.Email, trailerSuffix(coAuthor))
+				report.added(coAuthor)
+				added = true
+			}
 		}
 	}
 
 	// Display all collected warnings
 	for _, warning := range warnings {
-		fmt.Println(warning)
+		notef("%s\n", warning)
 	}
 
 	// Only update the template if at least one co-author was added
 	if added {
-		if err := gittemplate.UpdateTemplate(activeCoAuthors); err != nil {
+		if err := updateTemplate(cmd, scope, activeCoAuthors); err != nil {
 			return err
 		}
-	} else {
+	}
+
+	report.setActive(activeCoAuthors)
+	if err := printReport(report); err != nil {
+		return err
+	}
+
+	if !added {
 		return fmt.Errorf("no co-authors were added")
 	}
 
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/philippeckel/pair/internal/amend"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	amendRange string
+	amendForce bool
+)
+
+// amendCmd adds co-author trailers to commits that were made without them
+var amendCmd = &cobra.Command{
+	Use:   "amend [alias, group or index]...",
+	Short: "Add co-authors to existing commits",
+	Long: `Rewrite the message of the HEAD commit, or of every commit in --range, to credit
+the given co-authors, or the active ones if none are given. Trailers that are already
+present are not added twice and the author of a commit is never added as co-author.
+Commits that are already on a remote branch are refused unless --force is given.`,
+	Example: "pair amend\n" +
+		"pair amend jane john\n" +
+		"pair amend squad --range origin/main..HEAD",
+	RunE: amendCommits,
+}
+
+func amendCommits(cmd *cobra.Command, args []string) error {
+	if err := config.LoadConfig(); err != nil {
+		return err
+	}
+
+	var coAuthors []models.CoAuthor
+	for _, identifier := range args {
+		resolved, err := resolveCoAuthors(identifier)
+		if err != nil {
+			return fmt.Errorf("error with '%s': %w", identifier, err)
+		}
+		coAuthors = append(coAuthors, resolved...)
+	}
+
+	if len(args) == 0 {
+		scope, err := currentScope()
+		if err != nil {
+			return err
+		}
+
+		_, activeCoAuthors, err := loadActiveCoAuthors(scope)
+		if err != nil {
+			return err
+		}
+		coAuthors = activeCoAuthors
+	}
+
+	if len(coAuthors) == 0 {
+		return fmt.Errorf("no co-authors to add, pass aliases or add co-authors first")
+	}
+
+	// Credit the emails the roster selects for this repository, as the hook does
+	coAuthors = gittemplate.CreditedEmails(coAuthors, config.Config.CoAuthors)
+
+	var rewrites []amend.Rewrite
+	var err error
+	if amendRange != "" {
+		rewrites, err = amend.Commits(amendRange, coAuthors, amendForce)
+	} else {
+		rewrites, err = amend.Head(coAuthors, amendForce)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(rewrites) == 0 {
+		fmt.Println("All commits already credit these co-authors")
+		return nil
+	}
+
+	for _, rewrite := range rewrites {
+		fmt.Printf("Rewrote %.7s as %.7s %s\n", rewrite.Old, rewrite.New, rewrite.Subject)
+	}
+	return nil
+}
+
+func init() {
+	amendCmd.Flags().StringVarP(&amendRange, "range", "r", "", "rewrite every commit in this range ending at HEAD, e.g. origin/main..HEAD")
+	amendCmd.Flags().BoolVarP(&amendForce, "force", "f", false, "rewrite commits even if they are already on a remote branch")
+}
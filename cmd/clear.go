@@ -2,14 +2,35 @@ package commands
 
 import (
 	"fmt"
-	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/config"
 	"github.com/spf13/cobra"
 )
 
-func clearCoAuthors(cmd *cobra.Command, args []string) {
-	if err := gittemplate.ClearTemplate(); err != nil {
-		fmt.Printf("Error clearing co-authors: %v\n", err)
-		return
+func clearCoAuthors(cmd *cobra.Command, args []string) error {
+	scope, err := currentScope()
+	if err != nil {
+		return err
 	}
-	fmt.Println("All co-authors have been cleared")
+
+	// Clearing must work even if the template can no longer be read
+	_, activeCoAuthors, err := loadActiveCoAuthors(scope)
+	if err != nil {
+		activeCoAuthors = nil
+	}
+
+	if err := clearTemplate(scope); err != nil {
+		return fmt.Errorf("error clearing co-authors: %w", err)
+	}
+	notef("All co-authors have been cleared from %s scope\n", scope)
+
+	if !isTableOutput() {
+		// Aliases are only needed for the report
+		_ = config.LoadConfig()
+	}
+
+	report := newChangeReport()
+	for _, author := range activeCoAuthors {
+		report.removed(author)
+	}
+	return printReport(report)
 }
@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// peekActiveCoAuthors returns the active co-authors without changing anything.
+// Completions must not print or rewrite templates, so an expired session is
+// reported as empty instead of being cleared.
+func peekActiveCoAuthors() []models.CoAuthor {
+	scope, err := currentScope()
+	if err != nil {
+		return nil
+	}
+
+	templatePath, err := gittemplate.GetCurrentTemplate(scope)
+	if err != nil || templatePath == "" {
+		return nil
+	}
+
+	if expires, err := gittemplate.ReadExpiry(templatePath); err != nil || (!expires.IsZero() && !time.Now().Before(expires)) {
+		return nil
+	}
+
+	activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	if err != nil {
+		return nil
+	}
+	return activeCoAuthors
+}
+
+// describe formats a co-author as completion description
+func describe(author models.CoAuthor) string {
+	return fmt.Sprintf("%s <%s>", author.Name, author.Email)
+}
+
+// completeRoster completes aliases and group names from the roster, leaving out
+// arguments that were already given. With skipActive, co-authors that are
+// already active are left out as well.
+func completeRoster(skipActive bool) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if err := config.LoadConfig(); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		given := make(map[string]bool)
+		for _, arg := range args {
+			given[arg] = true
+		}
+
+		var activeCoAuthors []models.CoAuthor
+		if skipActive {
+			activeCoAuthors = peekActiveCoAuthors()
+		}
+		matcher := newMatcher()
+
+		var completions []string
+		for _, author := range config.Config.CoAuthors {
+			if given[author.Alias] || matcher.Contains(activeCoAuthors, author.Email) {
+				continue
+			}
+			completions = append(completions, author.Alias+"\t"+describe(author))
+		}
+
+		for _, name := range config.Config.GroupNames {
+			if given[name] {
+				continue
+			}
+			completions = append(completions, name+"\tgroup")
+		}
+
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeActive completes the currently active co-authors by alias, falling back
+// to their index for co-authors that are not in the roster
+func completeActive(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// Aliases are optional, co-authors without one are completed by index
+	_ = config.LoadConfig()
+
+	activeCoAuthors := peekActiveCoAuthors()
+	matcher := newMatcher()
+
+	var completions []string
+	for i, author := range activeCoAuthors {
+		alias := aliasFor(author)
+		if alias == "" {
+			alias = strconv.Itoa(i)
+		}
+		completions = append(completions, alias+"\t"+describe(author))
+	}
+
+	for _, name := range config.Config.GroupNames {
+		for _, member := range config.Config.Groups[name] {
+			if matcher.Contains(activeCoAuthors, config.Config.CoAuthorsMap[member].Email) {
+				completions = append(completions, name+"\tgroup")
+				break
+			}
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAlias completes the first argument with the aliases of the roster
+func completeAlias(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || config.LoadConfig() != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, author := range config.Config.CoAuthors {
+		completions = append(completions, author.Alias+"\t"+describe(author))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTrailerKeys completes --as with the known trailer keys in lower case
+func completeTrailerKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Custom keys of the roster are optional
+	_ = config.LoadConfig()
+
+	var completions []string
+	for _, key := range config.TrailerKeys() {
+		completions = append(completions, strings.ToLower(key))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	addCmd.ValidArgsFunction = completeRoster(true)
+	removeCmd.ValidArgsFunction = completeActive
+	mobStartCmd.ValidArgsFunction = completeRoster(false)
+	amendCmd.ValidArgsFunction = completeRoster(false)
+	configEditCmd.ValidArgsFunction = completeAlias
+	configRenameCmd.ValidArgsFunction = completeAlias
+	configDeleteCmd.ValidArgsFunction = completeAlias
+}
@@ -0,0 +1,572 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/mob"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configName      string
+	configEmail     string
+	configConvertTo string
+	configDryRun    bool
+)
+
+// configCmd groups the commands changing the roster file
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the co-authors in the roster",
+	Long: `Add, edit, rename and delete co-authors in the roster file without editing it by hand.
+Changes to a co-author are applied to the active co-authors and groups as well.`,
+}
+
+var configAddCmd = &cobra.Command{
+	Use:     "add <alias> --name <name> --email <email>",
+	Short:   "Add a co-author to the roster",
+	Example: "pair config add jane --name \"Jane Doe\" --email jane.doe@example.com",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.LoadConfigForWrite(); err != nil {
+			return err
+		}
+
+		alias := args[0]
+		if err := config.AddCoAuthor(alias, models.CoAuthor{Name: configName, Email: configEmail}); err != nil {
+			return err
+		}
+
+		if err := config.SaveConfig(); err != nil {
+			return err
+		}
+
+		notef("Added co-author %s: %s <%s>\n", alias, configName, configEmail)
+		return nil
+	},
+}
+
+var configEditCmd = &cobra.Command{
+	Use:     "edit <alias> [--name <name>] [--email <email>]",
+	Short:   "Change the name or email of a co-author",
+	Example: "pair config edit jane --email jane@new-company.example.com",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("name") && !cmd.Flags().Changed("email") {
+			return fmt.Errorf("nothing to change, pass --name or --email")
+		}
+
+		if err := config.LoadConfigForWrite(); err != nil {
+			return err
+		}
+
+		alias := args[0]
+		previous, exists := config.Config.CoAuthorsMap[alias]
+		if !exists {
+			return fmt.Errorf("no co-author found with alias '%s'", alias)
+		}
+
+		updated := previous
+		if cmd.Flags().Changed("name") {
+			updated.Name = configName
+		}
+		if cmd.Flags().Changed("email") {
+			updated.Email = configEmail
+		}
+
+		// Compare against the previous roster, the old address is not in the new one
+		matcher := newMatcher()
+
+		if err := config.UpdateCoAuthor(alias, updated); err != nil {
+			return err
+		}
+		if err := config.SaveConfig(); err != nil {
+			return err
+		}
+		notef("Updated co-author %s: %s <%s>\n", alias, updated.Name, updated.Email)
+
+		if err := updateMobMembers(func(member *mob.Member) {
+			if matcher.Same(member.Email, previous.Email) {
+				member.Name, member.Email = updated.Name, updated.Email
+			}
+		}); err != nil {
+			return err
+		}
+
+		return rewriteActiveTemplates(func(author models.CoAuthor) (models.CoAuthor, bool) {
+			if matcher.Same(author.Email, previous.Email) {
+				return updated, true
+			}
+			return author, true
+		})
+	},
+}
+
+var configRenameCmd = &cobra.Command{
+	Use:     "rename <old alias> <new alias>",
+	Short:   "Change the alias of a co-author",
+	Example: "pair config rename jane jd",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.LoadConfigForWrite(); err != nil {
+			return err
+		}
+
+		oldAlias, newAlias := args[0], args[1]
+		if err := config.RenameCoAuthor(oldAlias, newAlias); err != nil {
+			return err
+		}
+		if err := config.SaveConfig(); err != nil {
+			return err
+		}
+
+		if err := updateMobMembers(func(member *mob.Member) {
+			if member.Alias == oldAlias {
+				member.Alias = newAlias
+			}
+		}); err != nil {
+			return err
+		}
+
+		notef("Renamed co-author %s to %s\n", oldAlias, newAlias)
+		return nil
+	},
+}
+
+var configDeleteCmd = &cobra.Command{
+	Use:     "delete <alias>",
+	Short:   "Delete a co-author from the roster",
+	Long:    "Delete a co-author from the roster and from all groups. The co-author is no longer credited in active templates.",
+	Example: "pair config delete jane",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.LoadConfigForWrite(); err != nil {
+			return err
+		}
+
+		alias := args[0]
+		deleted, exists := config.Config.CoAuthorsMap[alias]
+		if !exists {
+			return fmt.Errorf("no co-author found with alias '%s'", alias)
+		}
+		matcher := newMatcher()
+
+		state, err := mob.Load()
+		if err != nil {
+			return err
+		}
+		if state != nil {
+			for _, member := range state.Members {
+				if matcher.Same(member.Email, deleted.Email) {
+					return fmt.Errorf("%s is part of the running mob, stop it first with 'pair mob stop'", alias)
+				}
+			}
+		}
+
+		deletedGroups, err := config.DeleteCoAuthor(alias)
+		if err != nil {
+			return err
+		}
+		if err := config.SaveConfig(); err != nil {
+			return err
+		}
+
+		notef("Deleted co-author %s: %s <%s>\n", alias, deleted.Name, deleted.Email)
+		for _, name := range deletedGroups {
+			notef("Deleted group %s, it has no members left\n", name)
+		}
+
+		return rewriteActiveTemplates(func(author models.CoAuthor) (models.CoAuthor, bool) {
+			return author, !matcher.Same(author.Email, deleted.Email)
+		})
+	},
+}
+
+var configSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Show which roster files the co-authors and groups come from",
+	Long: `Show the roster files that were merged, lowest precedence first, and the file
+every co-author and group was taken from. Rosters are read from the system file,
+~/.pair.json and the .pair.json files from the repository root down to the current
+directory. An entry replaces the entry with the same alias in the files before it,
+and null removes it.`,
+	Example: "pair config sources\n" +
+		"pair config sources --output json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.LoadConfig(); err != nil {
+			return err
+		}
+
+		report := newSourcesReport()
+		switch outputFormat() {
+		case outputJSON, outputYAML:
+			return encode(report)
+		case outputCSV, outputPlain:
+			return fmt.Errorf("config sources supports table, json and yaml output")
+		}
+
+		t := newTable("Roster files:")
+		t.AppendHeader(table.Row{"Layer", "Path", "Co-authors", "Groups", "Removed"})
+		for _, file := range report.Files {
+			t.AppendRow(table.Row{file.Layer, file.Path, len(file.CoAuthors), len(file.Groups), strings.Join(file.Removed, ", ")})
+		}
+		t.Render()
+
+		fmt.Println()
+		t = newTable("Entries:")
+		t.AppendHeader(table.Row{"Kind", "Name", "Layer", "Path", "Overrides"})
+		for _, entry := range report.Entries {
+			t.AppendRow(table.Row{entry.Kind, entry.Name, entry.Layer, entry.Path, strings.Join(entry.Overrides, "\n")})
+		}
+		t.Render()
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the roster files and report every problem",
+	Long: `Check all roster files that are merged, or only the one given with --config, and
+report every problem with its file, line and column instead of stopping at the first
+one. Errors, such as invalid emails or names, duplicate aliases or groups with unknown
+members, keep pair from loading the roster. Warnings, such as an email used by two
+co-authors, do not. The command fails if there is any error.`,
+	Example: "pair config validate\n" +
+		"pair config validate --config team.json --output plain",
+	Args: cobra.NoArgs,
+	// Problems in the roster are no usage error
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		problems, err := config.Validate()
+		if err != nil {
+			return err
+		}
+
+		report := newValidateReport(problems)
+		if err := printValidateReport(report); err != nil {
+			return err
+		}
+
+		if errors := len(report.Problems) - report.Warnings; errors > 0 {
+			return fmt.Errorf("found %d errors in the roster", errors)
+		}
+		return nil
+	},
+}
+
+var configConvertCmd = &cobra.Command{
+	Use:   "convert --to <format>",
+	Short: "Convert the roster file to JSON, YAML or TOML",
+	Long: `Rewrite the roster file changes are written to in another format and remove the
+original. The new file has the same name with the extension of the format, e.g.
+~/.pair.json becomes ~/.pair.yaml. The order of co-authors and groups is kept, comments
+are not.`,
+	Example: "pair config convert --to yaml\n" +
+		"pair config convert --to toml --config team.json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := config.GetConfigPath()
+		converted, err := config.ConvertFile(path, strings.ToLower(configConvertTo))
+		if err != nil {
+			return err
+		}
+
+		notef("Converted %s to %s\n", path, converted)
+		return nil
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the roster file to the current format",
+	Long: `Upgrade the roster file changes are written to to the current format version.
+Older rosters are upgraded in memory whenever they are read, this writes the result
+back. Use --dry-run to see the changes without writing them.`,
+	Example: "pair config migrate --dry-run\n" +
+		"pair config migrate --config team.json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		plan, err := config.PlanMigration()
+		if err != nil {
+			return err
+		}
+
+		if plan.From == plan.To {
+			notef("%s is already at version %d\n", plan.Path, plan.To)
+			return nil
+		}
+
+		if configDryRun {
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        diffLines(plan.Before),
+				B:        diffLines(plan.After),
+				FromFile: plan.Path + " (version " + strconv.Itoa(plan.From) + ")",
+				ToFile:   plan.Path + " (version " + strconv.Itoa(plan.To) + ")",
+				Context:  3,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Print(diff)
+			return nil
+		}
+
+		if err := config.SaveConfig(); err != nil {
+			return err
+		}
+		notef("Migrated %s from version %d to %d\n", plan.Path, plan.From, plan.To)
+		return nil
+	},
+}
+
+// diffLines splits a file into lines for a diff, each ending with a newline
+func diffLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// sourcesReport is the machine-readable output of "pair config sources"
+type sourcesReport struct {
+	Files   []sourceFileRecord  `json:"files" yaml:"files"`
+	Entries []sourceEntryRecord `json:"entries" yaml:"entries"`
+}
+
+// sourceFileRecord is a roster file and the entries it defines
+type sourceFileRecord struct {
+	Layer     string   `json:"layer" yaml:"layer"`
+	Path      string   `json:"path" yaml:"path"`
+	CoAuthors []string `json:"coauthors" yaml:"coauthors"`
+	Groups    []string `json:"groups" yaml:"groups"`
+	Removed   []string `json:"removed" yaml:"removed"`
+}
+
+// sourceEntryRecord is a co-author or group of the merged roster and the file
+// it was taken from
+type sourceEntryRecord struct {
+	Kind      string   `json:"kind" yaml:"kind"` // "coauthor" or "group"
+	Name      string   `json:"name" yaml:"name"`
+	Layer     string   `json:"layer" yaml:"layer"`
+	Path      string   `json:"path" yaml:"path"`
+	Overrides []string `json:"overrides" yaml:"overrides"` // Files with entries it replaces
+}
+
+// newSourcesReport describes config.Sources and the origin of every entry of the loaded roster
+func newSourcesReport() sourcesReport {
+	report := sourcesReport{Files: []sourceFileRecord{}, Entries: []sourceEntryRecord{}}
+	for _, source := range config.Sources {
+		report.Files = append(report.Files, sourceFileRecord{
+			Layer:     source.Layer,
+			Path:      source.Path,
+			CoAuthors: nonNil(source.CoAuthors),
+			Groups:    nonNil(source.Groups),
+			Removed:   nonNil(source.Removed),
+		})
+	}
+
+	origin := func(kind, name string, defines func(source config.Source) []string) sourceEntryRecord {
+		entry := sourceEntryRecord{Kind: kind, Name: name, Overrides: []string{}}
+		for _, source := range config.Sources {
+			for _, defined := range defines(source) {
+				if defined != name {
+					continue
+				}
+				if entry.Path != "" {
+					entry.Overrides = append(entry.Overrides, entry.Path)
+				}
+				entry.Layer, entry.Path = source.Layer, source.Path
+			}
+		}
+		return entry
+	}
+
+	for _, author := range config.Config.CoAuthors {
+		report.Entries = append(report.Entries, origin("coauthor", author.Alias, func(source config.Source) []string {
+			return source.CoAuthors
+		}))
+	}
+	for _, name := range config.Config.GroupNames {
+		report.Entries = append(report.Entries, origin("group", name, func(source config.Source) []string {
+			return source.Groups
+		}))
+	}
+	return report
+}
+
+// validateReport is the machine-readable output of "pair config validate"
+type validateReport struct {
+	Valid    bool            `json:"valid" yaml:"valid"` // No errors, there may be warnings
+	Files    []string        `json:"files" yaml:"files"`
+	Warnings int             `json:"warnings" yaml:"warnings"`
+	Problems []problemRecord `json:"problems" yaml:"problems"`
+}
+
+// problemRecord is a problem found in a roster file. Line and column are 0 if unknown.
+type problemRecord struct {
+	Severity string `json:"severity" yaml:"severity"`
+	Path     string `json:"path" yaml:"path"`
+	Line     int    `json:"line" yaml:"line"`
+	Column   int    `json:"column" yaml:"column"`
+	Key      string `json:"key" yaml:"key"`
+	Message  string `json:"message" yaml:"message"`
+
+	location string // path:line:column for humans
+}
+
+// newValidateReport describes the problems found in the files of config.Sources
+func newValidateReport(problems []config.Problem) validateReport {
+	report := validateReport{Valid: true, Files: []string{}, Problems: []problemRecord{}}
+	for _, source := range config.Sources {
+		report.Files = append(report.Files, source.Path)
+	}
+
+	for _, problem := range problems {
+		if problem.Severity == config.SeverityWarning {
+			report.Warnings++
+		} else {
+			report.Valid = false
+		}
+		report.Problems = append(report.Problems, problemRecord{
+			Severity: problem.Severity,
+			Path:     problem.Path,
+			Line:     problem.Line,
+			Column:   problem.Column,
+			Key:      problem.Key,
+			Message:  problem.Message,
+			location: problem.Location(),
+		})
+	}
+	return report
+}
+
+// printValidateReport writes the problems in the selected output format. Plain
+// output has one "path:line:column: severity: message" line per problem, which
+// editors can jump to.
+func printValidateReport(report validateReport) error {
+	switch outputFormat() {
+	case outputJSON, outputYAML:
+		return encode(report)
+	case outputCSV:
+		w := csv.NewWriter(outputWriter)
+		if err := w.Write([]string{"severity", "path", "line", "column", "key", "message"}); err != nil {
+			return err
+		}
+		for _, p := range report.Problems {
+			if err := w.Write([]string{p.Severity, p.Path, strconv.Itoa(p.Line), strconv.Itoa(p.Column), p.Key, p.Message}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	case outputPlain:
+		for _, p := range report.Problems {
+			fmt.Fprintf(outputWriter, "%s: %s: %s\n", p.location, p.Severity, p.Message)
+		}
+		return nil
+	}
+
+	if len(report.Problems) == 0 {
+		fmt.Printf("No problems found in %d roster files\n", len(report.Files))
+		return nil
+	}
+
+	t := newTable("Problems:")
+	t.AppendHeader(table.Row{"Severity", "Location", "Key", "Problem"})
+	for _, p := range report.Problems {
+		t.AppendRow(table.Row{p.Severity, p.location, p.Key, p.Message})
+	}
+	t.Render()
+	return nil
+}
+
+// nonNil returns list, or an empty list instead of nil so that JSON shows []
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+// rewriteActiveTemplates applies change to the active co-authors of every scope
+// with a pair template. change returns the replacement of a co-author and whether
+// to keep it. Scopes that are not available, such as local outside of a
+// repository, are skipped.
+func rewriteActiveTemplates(change func(author models.CoAuthor) (models.CoAuthor, bool)) error {
+	for _, scope := range gittemplate.Scopes {
+		templatePath, err := gittemplate.GetCurrentTemplate(scope)
+		if err != nil || templatePath == "" {
+			continue
+		}
+
+		activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+		if err != nil || len(activeCoAuthors) == 0 {
+			continue
+		}
+
+		changed := false
+		var updated []models.CoAuthor
+		for _, author := range activeCoAuthors {
+			replacement, keep := change(author)
+			replacement.TrailerKey = author.TrailerKey
+			if !keep || replacement.Name != author.Name || replacement.Email != author.Email {
+				changed = true
+			}
+			if keep {
+				updated = append(updated, replacement)
+			}
+		}
+
+		if !changed {
+			continue
+		}
+		if err := writeTemplate(scope, updated); err != nil {
+			return err
+		}
+		notef("Updated the active co-authors in %s scope\n", scope)
+	}
+	return nil
+}
+
+// updateMobMembers applies change to every member of the running mob, if any,
+// and saves the result
+func updateMobMembers(change func(member *mob.Member)) error {
+	state, err := mob.Load()
+	if err != nil || state == nil {
+		return err
+	}
+
+	for i := range state.Members {
+		change(&state.Members[i])
+	}
+	return mob.Save(state)
+}
+
+func init() {
+	configAddCmd.Flags().StringVar(&configName, "name", "", "name used in the trailer")
+	configAddCmd.Flags().StringVar(&configEmail, "email", "", "email used in the trailer")
+	_ = configAddCmd.MarkFlagRequired("name")
+	_ = configAddCmd.MarkFlagRequired("email")
+
+	configEditCmd.Flags().StringVar(&configName, "name", "", "new name")
+	configEditCmd.Flags().StringVar(&configEmail, "email", "", "new primary email")
+
+	configConvertCmd.Flags().StringVar(&configConvertTo, "to", "", "target format: "+strings.Join(config.Formats, ", "))
+	_ = configConvertCmd.MarkFlagRequired("to")
+	_ = configConvertCmd.RegisterFlagCompletionFunc("to", cobra.FixedCompletions(config.Formats, cobra.ShellCompDirectiveNoFileComp))
+
+	configMigrateCmd.Flags().BoolVar(&configDryRun, "dry-run", false, "show the changes without writing them")
+
+	configCmd.AddCommand(configAddCmd, configEditCmd, configRenameCmd, configDeleteCmd, configSourcesCmd, configValidateCmd, configConvertCmd, configMigrateCmd)
+}
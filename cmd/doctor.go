@@ -0,0 +1,434 @@
+package commands
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/githook"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/spf13/cobra"
+)
+
+// Results of a doctor check
+const (
+	checkPass = "pass"
+	checkWarn = "warn" // Works, but probably not as intended
+	checkFail = "fail" // Co-authors are not credited
+)
+
+// checkResult is the outcome of one doctor check, with a suggestion how to fix it
+type checkResult struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message" yaml:"message"`
+	Fix     string `json:"fix,omitempty" yaml:"fix,omitempty"`
+}
+
+// doctorReport is the machine-readable output of "pair doctor"
+type doctorReport struct {
+	Healthy  bool          `json:"healthy" yaml:"healthy"` // No check failed, there may be warnings
+	Platform string        `json:"platform" yaml:"platform"`
+	Checks   []checkResult `json:"checks" yaml:"checks"`
+}
+
+// doctorChecks run in order, from the basics to what builds upon them
+var doctorChecks = []func() checkResult{
+	checkGit,
+	checkSettings,
+	checkGitUser,
+	checkRoster,
+	checkTemplate,
+	checkHook,
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check why co-authors might not show up in commits",
+	Long: `Check everything pair relies on and suggest a fix for every problem: git and its
+user.name and user.email, the settings file, the roster files, which commit.template git
+uses in the current directory and whether a prepare-commit-msg hook is in the way.
+Every check passes, warns or fails. The command fails if any check fails.
+
+Use --output json to attach the results to a support ticket.`,
+	Example: "pair doctor\n" +
+		"pair doctor --output json",
+	Args: cobra.NoArgs,
+	// Problems found are no usage error
+	SilenceUsage: true,
+	// Run even with an invalid output format, it is reported as a problem
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report := runDoctor()
+		if err := printDoctorReport(report); err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, check := range report.Checks {
+			if check.Status == checkFail {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(report.Checks))
+		}
+		return nil
+	},
+}
+
+// runDoctor runs all checks
+func runDoctor() doctorReport {
+	report := doctorReport{Healthy: true, Platform: runtime.GOOS + "/" + runtime.GOARCH, Checks: []checkResult{}}
+
+	for _, check := range doctorChecks {
+		result := check()
+		if result.Status == checkFail {
+			report.Healthy = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	return report
+}
+
+// checkGit makes sure git can be run at all
+func checkGit() checkResult {
+	output, err := git.Run("--version")
+	if err != nil {
+		return checkResult{Name: "git", Status: checkFail,
+			Message: fmt.Sprintf("could not run git: %v", err),
+			Fix:     "Install git and make sure it is in PATH"}
+	}
+	return checkResult{Name: "git", Status: checkPass, Message: strings.TrimSpace(output)}
+}
+
+// checkSettings reads the settings file again and checks the values in effect,
+// which may also come from flags and environment variables
+func checkSettings() checkResult {
+	result := checkResult{Name: "settings", Status: checkPass}
+
+	path, keys, err := config.ReadSettings()
+	if err != nil {
+		result.Status = checkFail
+		result.Message = fmt.Sprintf("could not read settings file %s: %v", path, err)
+		result.Fix = "Fix the YAML syntax or remove the file to use the defaults"
+		return result
+	}
+
+	if _, err := currentScope(); err != nil {
+		result.Status = checkFail
+		result.Message = err.Error()
+		result.Fix = "Set scope to global, local or worktree in " + settingsName(path) + ", PAIR_SCOPE or --scope"
+		return result
+	}
+	if err := validateOutput(nil, nil); err != nil {
+		result.Status = checkFail
+		result.Message = err.Error()
+		result.Fix = "Set output to one of " + strings.Join(outputFormats, ", ") + " in " + settingsName(path) + ", PAIR_OUTPUT or --output"
+		return result
+	}
+
+	var unknown []string
+	for _, key := range keys {
+		if !contains(config.SettingKeys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	if len(unknown) > 0 {
+		result.Status = checkWarn
+		result.Message = fmt.Sprintf("%s sets unknown settings %s, which are ignored", path, strings.Join(unknown, ", "))
+		result.Fix = "Remove or rename them, known settings are " + strings.Join(config.SettingKeys, ", ")
+		return result
+	}
+
+	if templatePath := config.GetTemplatePath(); templatePath != "" && !filepath.IsAbs(templatePath) {
+		result.Status = checkWarn
+		result.Message = fmt.Sprintf("default_template_path '%s' is relative, the global template is written to a different file in every directory", templatePath)
+		result.Fix = "Set default_template_path to an absolute path in " + settingsName(path)
+		return result
+	}
+
+	result.Message = fmt.Sprintf("scope %s, output %s, global template %s", config.GetScope(), outputFormat(), config.GetTemplatePath())
+	if path == "" {
+		result.Message = "no settings file, using " + result.Message
+	} else {
+		result.Message = "using " + path + ": " + result.Message
+	}
+	return result
+}
+
+// settingsName names the settings file for suggestions
+func settingsName(path string) string {
+	if path == "" {
+		return "~/.config/pair/config.yaml"
+	}
+	return path
+}
+
+// checkGitUser makes sure git knows who commits, which pair needs to never add
+// the committer as their own co-author
+func checkGitUser() checkResult {
+	name, email, err := getGitUserInfo()
+	switch {
+	case err != nil && name == "":
+		return checkResult{Name: "user", Status: checkFail,
+			Message: "git user.name is not set",
+			Fix:     `Run git config --global user.name "Your Name"`}
+	case err != nil || email == "":
+		return checkResult{Name: "user", Status: checkFail,
+			Message: "git user.email is not set",
+			Fix:     "Run git config --global user.email you@example.com"}
+	}
+
+	result := checkResult{Name: "user", Status: checkPass, Message: fmt.Sprintf("committing as %s <%s>", name, email)}
+	if err := config.LoadConfig(); err == nil {
+		if author, ok := newMatcher().Lookup(email); ok {
+			result.Message += fmt.Sprintf(", who is '%s' in the roster and never added as co-author", author.Alias)
+		}
+	}
+	return result
+}
+
+// checkRoster validates the roster files and names the one changes are written to
+func checkRoster() checkResult {
+	problems, err := config.Validate()
+	if errors.Is(err, fs.ErrNotExist) {
+		return checkResult{Name: "roster", Status: checkFail,
+			Message: "no roster file found",
+			Fix:     "Run 'pair init' or 'pair import git-log' to create " + config.GetConfigPath()}
+	}
+	if err != nil {
+		return checkResult{Name: "roster", Status: checkFail, Message: err.Error()}
+	}
+
+	var files []string
+	for _, source := range config.Sources {
+		files = append(files, source.Path)
+	}
+	message := fmt.Sprintf("%d co-authors and %d groups from %s, changes are written to %s",
+		len(config.Config.CoAuthors), len(config.Config.Groups), strings.Join(files, ", "), config.GetConfigPath())
+
+	errorCount := 0
+	for _, problem := range problems {
+		if problem.Severity == config.SeverityError {
+			errorCount++
+		}
+	}
+	switch {
+	case errorCount > 0:
+		return checkResult{Name: "roster", Status: checkFail,
+			Message: fmt.Sprintf("%d errors keep pair from loading the roster, the first is %v", errorCount, firstError(problems)),
+			Fix:     "Run 'pair config validate' to list all problems with their location"}
+	case len(problems) > 0:
+		return checkResult{Name: "roster", Status: checkWarn,
+			Message: fmt.Sprintf("%s; %d warnings, the first is %v", message, len(problems), problems[0]),
+			Fix:     "Run 'pair config validate' to list all problems with their location"}
+	}
+	return checkResult{Name: "roster", Status: checkPass, Message: message}
+}
+
+// firstError returns the first problem that is an error
+func firstError(problems []config.Problem) config.Problem {
+	for _, problem := range problems {
+		if problem.Severity == config.SeverityError {
+			return problem
+		}
+	}
+	return config.Problem{}
+}
+
+// checkTemplate finds the commit template git uses in the current directory and
+// makes sure it is the one pair writes to
+func checkTemplate() checkResult {
+	result := checkResult{Name: "template", Status: checkWarn}
+
+	scope, err := currentScope()
+	if err != nil {
+		result.Status = checkFail
+		result.Message = err.Error()
+		result.Fix = "See the settings check"
+		return result
+	}
+
+	effectiveScope, templatePath, err := gittemplate.GetEffectiveTemplate()
+	if err != nil {
+		result.Status = checkFail
+		result.Message = fmt.Sprintf("could not read commit.template: %v", err)
+		return result
+	}
+	if templatePath == "" {
+		result.Message = "commit.template is not set, no co-authors are credited"
+		result.Fix = "Add co-authors with 'pair add <alias>'"
+		return result
+	}
+
+	if _, err := gittemplate.ReadExpiry(templatePath); errors.Is(err, fs.ErrNotExist) {
+		result.Status = checkFail
+		result.Message = fmt.Sprintf("commit.template points to %s, which does not exist, so git commit fails", templatePath)
+		result.Fix = fmt.Sprintf("Run 'pair clear --scope %s' and add the co-authors again", orScope(effectiveScope, scope))
+		return result
+	}
+
+	configured, err := gittemplate.GetCurrentTemplate(scope)
+	if err != nil {
+		result.Status = checkFail
+		result.Message = fmt.Sprintf("could not read commit.template: %v", err)
+		return result
+	}
+
+	switch {
+	case effectiveScope == "":
+		result.Message = fmt.Sprintf("git uses %s from the system git config or the command line, which pair does not manage", templatePath)
+		result.Fix = "Remove it with 'git config --system --unset commit.template' or check GIT_CONFIG_* variables"
+		return result
+	case effectiveScope != scope && configured != "":
+		result.Message = fmt.Sprintf("the %s template %s overrides the %s one pair writes to", effectiveScope, templatePath, scope)
+		result.Fix = fmt.Sprintf("Run 'pair clear --scope %s', or use --scope %s", effectiveScope, effectiveScope)
+		return result
+	case effectiveScope != scope:
+		result.Message = fmt.Sprintf("pair writes to the %s scope, but git uses the %s template %s", scope, effectiveScope, templatePath)
+		result.Fix = fmt.Sprintf("Use --scope %s or set scope: %s in the settings file", effectiveScope, effectiveScope)
+		return result
+	case !gittemplate.IsPairTemplate(templatePath):
+		result.Message = fmt.Sprintf("%s was not written by pair and credits no co-authors", templatePath)
+		result.Fix = "Add co-authors with 'pair add <alias>', pair keeps the template as the base of its own"
+		return result
+	}
+
+	expires, err := gittemplate.ReadExpiry(templatePath)
+	if err != nil {
+		result.Status = checkFail
+		result.Message = err.Error()
+		result.Fix = fmt.Sprintf("Run 'pair clear --scope %s' and add the co-authors again", scope)
+		return result
+	}
+	if !expires.IsZero() && !time.Now().Before(expires) {
+		result.Message = fmt.Sprintf("the pairing session in %s ended at %s, its co-authors are no longer credited", templatePath, expires.Local().Format(sessionTimeLayout))
+		result.Fix = "Start a new session with 'pair add <alias>'"
+		return result
+	}
+
+	active, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	if err != nil {
+		result.Status = checkFail
+		result.Message = err.Error()
+		return result
+	}
+	if len(active) == 0 {
+		result.Message = fmt.Sprintf("%s (%s scope) credits no co-authors", templatePath, scope)
+		result.Fix = "Add co-authors with 'pair add <alias>'"
+		return result
+	}
+
+	result.Status = checkPass
+	result.Message = fmt.Sprintf("%s (%s scope) credits %d co-authors", templatePath, scope, len(active))
+	return result
+}
+
+// orScope returns scope, or fallback if it is empty
+func orScope(scope, fallback gittemplate.Scope) gittemplate.Scope {
+	if scope == "" {
+		return fallback
+	}
+	return scope
+}
+
+// checkHook looks for a prepare-commit-msg hook that might replace the template
+// and makes sure pair's own hook can run
+func checkHook() checkResult {
+	result := checkResult{Name: "hook", Status: checkPass}
+
+	installed, path, err := githook.Installed()
+	if err != nil {
+		result.Message = "not in a git repository, no hook to check"
+		return result
+	}
+
+	info, statErr := os.Stat(path)
+	switch {
+	case errors.Is(statErr, fs.ErrNotExist):
+		result.Message = "no prepare-commit-msg hook, commits made with -m, from IDEs or merges get no co-authors"
+		result.Fix = "Run 'pair hook install' to credit the co-authors there as well"
+		return result
+	case statErr != nil:
+		result.Status = checkFail
+		result.Message = fmt.Sprintf("could not check the hook: %v", statErr)
+		return result
+	case !installed:
+		result.Status = checkWarn
+		result.Message = fmt.Sprintf("%s was not installed by pair and may replace the commit template", path)
+		result.Fix = "Run 'pair hook install', which keeps the hook and runs it before pair"
+		return result
+	case runtime.GOOS != "windows" && info.Mode()&0111 == 0:
+		result.Status = checkFail
+		result.Message = fmt.Sprintf("%s is not executable, git skips it", path)
+		result.Fix = "Run chmod +x " + path
+		return result
+	}
+
+	if _, err := exec.LookPath("pair"); err != nil {
+		result.Status = checkFail
+		result.Message = fmt.Sprintf("%s is installed, but cannot find pair in PATH and credits nobody", path)
+		result.Fix = "Add the directory of the pair binary to PATH"
+		return result
+	}
+
+	result.Message = "pair hook installed at " + path
+	return result
+}
+
+// contains reports whether list holds value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// printDoctorReport writes the results in the selected output format. Unknown
+// formats fall back to the table, they are reported by the settings check.
+func printDoctorReport(report doctorReport) error {
+	switch outputFormat() {
+	case outputJSON, outputYAML:
+		return encode(report)
+	case outputCSV:
+		w := csv.NewWriter(outputWriter)
+		if err := w.Write([]string{"name", "status", "message", "fix"}); err != nil {
+			return err
+		}
+		for _, check := range report.Checks {
+			if err := w.Write([]string{check.Name, check.Status, check.Message, check.Fix}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	case outputPlain:
+		for _, check := range report.Checks {
+			fmt.Fprintf(outputWriter, "%s: %s: %s\n", check.Name, check.Status, check.Message)
+		}
+		return nil
+	}
+
+	t := newTable("Checks:")
+	t.AppendHeader(table.Row{"Status", "Check", "Result", "Fix"})
+	for _, check := range report.Checks {
+		t.AppendRow(table.Row{check.Status, check.Name, check.Message, check.Fix})
+	}
+	t.Render()
+	return nil
+}
@@ -0,0 +1,943 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/journal"
+	"github.com/philippeckel/pair/internal/mob"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRoster = `{
+  "coauthors": {
+    "jane": {"name": "Jane Smith", "email": "jane@example.com"},
+    "john": {"name": "John Doe", "email": "john@example.com"},
+    "sam": {"name": "Sam Johnson", "email": "sam@example.com"},
+    "me": {"name": "Me Myself", "email": "me@example.com"}
+  },
+  "groups": {
+    "squad": ["jane", "john", "me"]
+  }
+}`
+
+// setupFakeGit points all git calls to an in-memory fake and writes the
+// roster and templates into a temporary directory
+func setupFakeGit(t *testing.T) *git.Fake {
+	t.Helper()
+	dir := t.TempDir()
+	// Keep the mob state and other files in the home directory out of the way
+	t.Setenv("HOME", dir)
+
+	fake := git.NewFake()
+	fake.GitDir = filepath.Join(dir, "repo", ".git")
+	require.NoError(t, os.MkdirAll(fake.GitDir, 0755))
+	fake.Config["global"]["user.name"] = "Me Myself"
+	fake.Config["global"]["user.email"] = "me@example.com"
+
+	previousRunner := git.Default
+	previousConfigPath := config.ConfigPath
+	git.Default = fake
+	t.Cleanup(func() {
+		git.Default = previousRunner
+		config.ConfigPath = previousConfigPath
+		viper.Reset()
+	})
+
+	config.ConfigPath = filepath.Join(dir, ".pair.json")
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte(testRoster), 0644))
+	require.NoError(t, config.LoadConfig())
+
+	viper.Set("default_template_path", filepath.Join(dir, "git_commit_template"))
+	viper.Set("scope", "global")
+
+	return fake
+}
+
+// activateAliases writes a template with the given aliases as active co-authors
+func activateAliases(t *testing.T, scope gittemplate.Scope, aliases []string) {
+	t.Helper()
+	if len(aliases) == 0 {
+		return
+	}
+	var authors []models.CoAuthor
+	for _, alias := range aliases {
+		authors = append(authors, config.Config.CoAuthorsMap[alias])
+	}
+	require.NoError(t, gittemplate.UpdateTemplate(scope, authors))
+}
+
+// activeEmails returns the emails of the active co-authors in the given scope
+func activeEmails(t *testing.T, scope gittemplate.Scope) []string {
+	t.Helper()
+	templatePath, err := gittemplate.GetCurrentTemplate(scope)
+	require.NoError(t, err)
+
+	authors, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	require.NoError(t, err)
+
+	var emails []string
+	for _, author := range authors {
+		emails = append(emails, author.Email)
+	}
+	return emails
+}
+
+// pickFirst makes the fuzzy finder select the first n entries
+func pickFirst(t *testing.T, n int) {
+	t.Helper()
+	previous := findMulti
+	findMulti = func(slice interface{}, itemFunc func(i int) string, opts ...fuzzyfinder.Option) ([]int, error) {
+		var indices []int
+		for i := 0; i < n; i++ {
+			indices = append(indices, i)
+		}
+		return indices, nil
+	}
+	t.Cleanup(func() { findMulti = previous })
+}
+
+func TestCoAuthorFlows(t *testing.T) {
+	tests := []struct {
+		name      string
+		scope     gittemplate.Scope
+		active    []string
+		picks     int
+		run       func() error
+		expectErr string
+		expect    []string
+	}{
+		{
+			name:   "add single alias",
+			run:    func() error { return addCoAuthor(addCmd, []string{"jane"}) },
+			expect: []string{"jane@example.com"},
+		},
+		{
+			name:   "add multiple aliases and indices",
+			run:    func() error { return addCoAuthor(addCmd, []string{"jane", "2"}) },
+			expect: []string{"jane@example.com", "sam@example.com"},
+		},
+		{
+			name:   "add keeps already active co-authors",
+			active: []string{"john"},
+			run:    func() error { return addCoAuthor(addCmd, []string{"jane"}) },
+			expect: []string{"john@example.com", "jane@example.com"},
+		},
+		{
+			name:      "add already active co-author",
+			active:    []string{"jane"},
+			run:       func() error { return addCoAuthor(addCmd, []string{"jane"}) },
+			expectErr: "no co-authors were added",
+			expect:    []string{"jane@example.com"},
+		},
+		{
+			name:      "add yourself",
+			run:       func() error { return addCoAuthor(addCmd, []string{"me"}) },
+			expectErr: "no co-authors were added",
+		},
+		{
+			name:      "add unknown alias",
+			run:       func() error { return addCoAuthor(addCmd, []string{"nobody"}) },
+			expectErr: "no co-author found with alias 'nobody'",
+		},
+		{
+			name:   "add group skips yourself",
+			run:    func() error { return addCoAuthor(addCmd, []string{"squad"}) },
+			expect: []string{"jane@example.com", "john@example.com"},
+		},
+		{
+			name:   "add in local scope",
+			scope:  gittemplate.ScopeLocal,
+			run:    func() error { return addCoAuthor(addCmd, []string{"john"}) },
+			expect: []string{"john@example.com"},
+		},
+		{
+			name:   "remove by alias",
+			active: []string{"jane", "john"},
+			run:    func() error { return removeCoAuthor(removeCmd, []string{"jane"}) },
+			expect: []string{"john@example.com"},
+		},
+		{
+			name:   "remove by active index",
+			active: []string{"jane", "john"},
+			run:    func() error { return removeCoAuthor(removeCmd, []string{"1"}) },
+			expect: []string{"jane@example.com"},
+		},
+		{
+			name:   "remove group",
+			active: []string{"jane", "sam", "john"},
+			run:    func() error { return removeCoAuthor(removeCmd, []string{"squad"}) },
+			expect: []string{"sam@example.com"},
+		},
+		{
+			name:      "remove group without active members",
+			active:    []string{"sam"},
+			run:       func() error { return removeCoAuthor(removeCmd, []string{"squad"}) },
+			expectErr: "no member of group 'squad' is currently active",
+			expect:    []string{"sam@example.com"},
+		},
+		{
+			name:      "remove inactive co-author",
+			active:    []string{"jane"},
+			run:       func() error { return removeCoAuthor(removeCmd, []string{"sam"}) },
+			expectErr: "is not currently active",
+			expect:    []string{"jane@example.com"},
+		},
+		{
+			name:      "remove without active co-authors",
+			run:       func() error { return removeCoAuthor(removeCmd, []string{"jane"}) },
+			expectErr: "no active co-authors to remove",
+		},
+		{
+			name:   "select skips active co-authors and yourself",
+			active: []string{"jane"},
+			picks:  2,
+			run:    func() error { return selectCmd.RunE(selectCmd, nil) },
+			expect: []string{"jane@example.com", "john@example.com", "sam@example.com"},
+		},
+		{
+			name:      "select with everyone active",
+			active:    []string{"jane", "john", "sam"},
+			picks:     1,
+			run:       func() error { return selectCmd.RunE(selectCmd, nil) },
+			expectErr: "all co-authors are already active",
+			expect:    []string{"jane@example.com", "john@example.com", "sam@example.com"},
+		},
+		{
+			name:   "unselect removes picked co-authors",
+			active: []string{"jane", "john", "sam"},
+			picks:  2,
+			run:    func() error { return unselectCmd.RunE(unselectCmd, nil) },
+			expect: []string{"sam@example.com"},
+		},
+		{
+			name:      "unselect without active co-authors",
+			picks:     1,
+			run:       func() error { return unselectCmd.RunE(unselectCmd, nil) },
+			expectErr: "no active co-authors found",
+		},
+		{
+			name:   "clear",
+			active: []string{"jane", "john"},
+			run: func() error {
+				require.NoError(t, clearCoAuthors(clearCmd, nil))
+				return nil
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			setupFakeGit(t)
+			scope := tc.scope
+			if scope == "" {
+				scope = gittemplate.ScopeGlobal
+			}
+			viper.Set("scope", string(scope))
+			activateAliases(t, scope, tc.active)
+			pickFirst(t, tc.picks)
+
+			err := tc.run()
+			if tc.expectErr != "" {
+				assert.ErrorContains(t, err, tc.expectErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expect, activeEmails(t, scope))
+		})
+	}
+}
+
+func TestScopesAreIndependent(t *testing.T) {
+	fake := setupFakeGit(t)
+
+	viper.Set("scope", "local")
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane"}))
+
+	assert.Empty(t, activeEmails(t, gittemplate.ScopeGlobal))
+	assert.Equal(t, []string{"jane@example.com"}, activeEmails(t, gittemplate.ScopeLocal))
+	assert.Equal(t, filepath.Join(fake.GitDir, "pair_commit_template"), fake.Config["local"]["commit.template"])
+}
+
+func TestClearRestoresBaseTemplate(t *testing.T) {
+	fake := setupFakeGit(t)
+
+	basePath := filepath.Join(t.TempDir(), "team_template")
+	require.NoError(t, os.WriteFile(basePath, []byte("TICKET-: \n\n# Checklist\n"), 0644))
+	fake.Config["global"]["commit.template"] = basePath
+
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane"}))
+
+	templatePath := fake.Config["global"]["commit.template"]
+	assert.NotEqual(t, basePath, templatePath)
+	content, err := os.ReadFile(templatePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "TICKET-: \n\n# Checklist\n")
+	assert.Contains(t, string(content), "Co-authored-by: Jane Smith <jane@example.com>")
+
+	require.NoError(t, clearCoAuthors(clearCmd, nil))
+
+	assert.Equal(t, basePath, fake.Config["global"]["commit.template"])
+	assert.NotContains(t, fake.Config["global"], "pair.basetemplate")
+}
+
+func TestOtherAddressesMatchRosterEntries(t *testing.T) {
+	fake := setupFakeGit(t)
+	fake.Mailmap["jsmith@old.example.com"] = "Jane Smith <jane@example.com>"
+
+	roster := `{"coauthors": {
+		"jane": {"name": "Jane Smith", "email": "jane@example.com"},
+		"john": {"name": "John Doe", "email": "john@example.com", "emails": ["john@personal.example.org"]}
+	}}`
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte(roster), 0644))
+	require.NoError(t, config.LoadConfig())
+
+	// Templates written before the addresses changed
+	require.NoError(t, gittemplate.UpdateTemplate(gittemplate.ScopeGlobal, []models.CoAuthor{
+		{Name: "Jane Smith", Email: "jsmith@old.example.com"},
+		{Name: "John Doe", Email: "john@personal.example.org"},
+	}))
+
+	err := addCoAuthor(addCmd, []string{"jane", "john"})
+	assert.ErrorContains(t, err, "no co-authors were added")
+
+	_, active, err := loadActiveCoAuthors(gittemplate.ScopeGlobal)
+	require.NoError(t, err)
+	assert.Equal(t, "jane", aliasFor(active[0]))
+	assert.Equal(t, "john", aliasFor(active[1]))
+
+	// Addresses without rules only recognize the co-author, the trailer uses the primary one
+	require.NoError(t, removeCoAuthor(removeCmd, []string{"jane"}))
+	assert.Equal(t, []string{"john@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+}
+
+func TestEmailIsSelectedForRepository(t *testing.T) {
+	fake := setupFakeGit(t)
+	remotes := "origin\tgit@github.com:team/project.git (fetch)\norigin\tgit@github.com:team/project.git (push)\n"
+	fake.Handlers["remote"] = func(git.Command) (string, error) { return remotes, nil }
+
+	roster := `{"coauthors": {
+		"jane": {"name": "Jane Smith", "email": "jane@company.example.com", "emails": [
+			{"email": "jane@users.noreply.github.com", "hosts": ["github.com"]},
+			"jane@old.example.com"
+		]},
+		"john": {"name": "John Doe", "email": "john@company.example.com", "emails": [
+			{"email": "john@personal.example.org", "paths": ["` + filepath.Dir(fake.GitDir) + `"]}
+		]}
+	}}`
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte(roster), 0644))
+	require.NoError(t, config.LoadConfig())
+
+	// The global template is shared by all repositories and keeps the primary addresses
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane", "john"}))
+	assert.Equal(t, []string{"jane@company.example.com", "john@company.example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+
+	// The hook selects the addresses for the repository at commit time, with the roster it loads itself
+	var trailers []string
+	fake.Handlers["interpret-trailers"] = func(cmd git.Command) (string, error) {
+		trailers = nil
+		for i, arg := range cmd.Args {
+			if arg == "--trailer" {
+				trailers = append(trailers, cmd.Args[i+1])
+			}
+		}
+		return "", nil
+	}
+	message := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(message, []byte("Fix the build\n"), 0644))
+	config.Config = models.Config{}
+	require.NoError(t, hookRunCmd.RunE(hookRunCmd, []string{message}))
+	assert.Equal(t, []string{
+		"Co-authored-by: Jane Smith <jane@users.noreply.github.com>",
+		"Co-authored-by: John Doe <john@personal.example.org>",
+	}, trailers)
+
+	// In another repository the primary addresses are used
+	repository := fake.GitDir
+	remotes = "origin\thttps://git.company.example.com/team/project.git (fetch)\n"
+	fake.GitDir = filepath.Join(t.TempDir(), ".git")
+	require.NoError(t, os.MkdirAll(fake.GitDir, 0755))
+	require.NoError(t, hookRunCmd.RunE(hookRunCmd, []string{message}))
+	assert.Equal(t, []string{
+		"Co-authored-by: Jane Smith <jane@company.example.com>",
+		"Co-authored-by: John Doe <john@company.example.com>",
+	}, trailers)
+
+	// The template of a repository selects the addresses when it is written, also for active co-authors
+	remotes = "origin\tgit@github.com:team/project.git (fetch)\n"
+	fake.GitDir = repository
+	viper.Set("scope", "local")
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane", "john"}))
+	assert.Equal(t, []string{"jane@users.noreply.github.com", "john@personal.example.org"}, activeEmails(t, gittemplate.ScopeLocal))
+	require.NoError(t, removeCoAuthor(removeCmd, []string{"jane"}))
+	assert.Equal(t, []string{"john@personal.example.org"}, activeEmails(t, gittemplate.ScopeLocal))
+}
+
+func TestHookReplacesTrailersOfGlobalTemplate(t *testing.T) {
+	fake := setupFakeGit(t)
+	fake.Handlers["remote"] = func(git.Command) (string, error) {
+		return "origin\tgit@github.com:team/project.git (fetch)\n", nil
+	}
+	fake.Handlers["interpret-trailers"] = func(git.Command) (string, error) { return "", nil }
+
+	roster := `{"coauthors": {
+		"jane": {"name": "Jane Smith", "email": "jane@company.example.com", "emails": [
+			{"email": "jane@users.noreply.github.com", "hosts": ["github.com"]}
+		]},
+		"john": {"name": "John Doe", "email": "john@example.com"}
+	}}`
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte(roster), 0644))
+	require.NoError(t, config.LoadConfig())
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane", "john"}))
+
+	// Git starts the message with the global template, which has the primary address
+	template, err := os.ReadFile(fake.Config["global"]["commit.template"])
+	require.NoError(t, err)
+	message := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(message, template, 0644))
+
+	require.NoError(t, hookRunCmd.RunE(hookRunCmd, []string{message}))
+	data, err := os.ReadFile(message)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "jane@company.example.com")
+	assert.Contains(t, string(data), "Co-authored-by: John Doe <john@example.com>\n")
+}
+
+func TestAmendSelectsEmailForRepository(t *testing.T) {
+	fake := setupFakeGit(t)
+	fake.Handlers["remote"] = func(git.Command) (string, error) {
+		return "origin\tgit@github.com:team/project.git (fetch)\n", nil
+	}
+	roster := `{"coauthors": {
+		"jane": {"name": "Jane Smith", "email": "jane@company.example.com", "emails": [
+			{"email": "jane@users.noreply.github.com", "hosts": ["github.com"]}
+		]}
+	}}`
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte(roster), 0644))
+
+	// A single unpushed commit by someone else
+	fake.Handlers["rev-parse"] = func(git.Command) (string, error) { return "abc1234\n", nil }
+	fake.Handlers["rev-list"] = func(git.Command) (string, error) { return "abc1234\n", nil }
+	fake.Handlers["branch"] = func(git.Command) (string, error) { return "", nil }
+	fake.Handlers["log"] = func(cmd git.Command) (string, error) {
+		if cmd.Args[2] == "--format=%B" {
+			return "Fix the build\n", nil
+		}
+		return "Me Myself\x00me@example.com\x001700000000 +0000\n", nil
+	}
+	var trailers []string
+	fake.Handlers["interpret-trailers"] = func(cmd git.Command) (string, error) {
+		for i, arg := range cmd.Args {
+			if arg == "--trailer" {
+				trailers = append(trailers, cmd.Args[i+1])
+			}
+		}
+		return cmd.Stdin + "\n" + strings.Join(trailers, "\n") + "\n", nil
+	}
+	fake.Handlers["commit-tree"] = func(git.Command) (string, error) { return "def5678\n", nil }
+	fake.Handlers["update-ref"] = func(git.Command) (string, error) { return "", nil }
+
+	require.NoError(t, amendCommits(amendCmd, []string{"jane"}))
+	assert.Equal(t, []string{"Co-authored-by: Jane Smith <jane@users.noreply.github.com>"}, trailers)
+}
+
+func TestCommandsReturnErrors(t *testing.T) {
+	setupFakeGit(t)
+	viper.Set("scope", "repo")
+	for _, run := range []func(*cobra.Command, []string) error{showActiveCoAuthors, clearCoAuthors} {
+		assert.ErrorContains(t, run(nil, nil), "unknown scope 'repo'")
+	}
+
+	viper.Set("scope", "global")
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte("{"), 0644))
+	assert.Error(t, listCoAuthors(listCmd, nil))
+}
+
+func TestInitWritesFormatOfPath(t *testing.T) {
+	setupFakeGit(t)
+	dir := t.TempDir()
+
+	for _, name := range []string{"pair.json", "pair.yaml", "pair.toml"} {
+		config.ConfigPath = filepath.Join(dir, name)
+		require.NoError(t, initConfig(initCmd, nil))
+
+		require.NoError(t, config.LoadConfig(), name)
+		assert.Len(t, config.Config.CoAuthors, 2, name)
+		problems, err := config.Validate()
+		require.NoError(t, err)
+		assert.Empty(t, problems, name)
+	}
+}
+
+func TestConfigChangesUpdateActiveTemplates(t *testing.T) {
+	setupFakeGit(t)
+	activateAliases(t, gittemplate.ScopeGlobal, []string{"jane", "john"})
+	activateAliases(t, gittemplate.ScopeLocal, []string{"john"})
+
+	require.NoError(t, configEditCmd.Flags().Set("email", "john@new.example.com"))
+	t.Cleanup(func() { configEditCmd.Flags().Lookup("email").Changed = false })
+	require.NoError(t, configEditCmd.RunE(configEditCmd, []string{"john"}))
+
+	assert.Equal(t, []string{"jane@example.com", "john@new.example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+	assert.Equal(t, []string{"john@new.example.com"}, activeEmails(t, gittemplate.ScopeLocal))
+
+	require.NoError(t, configRenameCmd.RunE(configRenameCmd, []string{"john", "jd"}))
+	assert.Equal(t, []string{"jane", "jd", "me"}, config.Config.Groups["squad"])
+
+	require.NoError(t, configDeleteCmd.RunE(configDeleteCmd, []string{"jd"}))
+	assert.Equal(t, []string{"jane@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+	assert.Empty(t, activeEmails(t, gittemplate.ScopeLocal))
+
+	require.NoError(t, config.LoadConfig())
+	assert.NotContains(t, config.Config.CoAuthorsMap, "jd")
+	assert.Equal(t, []string{"jane", "me"}, config.Config.Groups["squad"])
+}
+
+func TestExpiredSessionIsCleared(t *testing.T) {
+	fake := setupFakeGit(t)
+
+	jane := config.Config.CoAuthorsMap["jane"]
+	require.NoError(t, gittemplate.UpdateTemplateUntil(gittemplate.ScopeGlobal, []models.CoAuthor{jane}, time.Now().Add(-time.Minute)))
+
+	require.NoError(t, addCoAuthor(addCmd, []string{"john"}))
+
+	assert.Equal(t, []string{"john@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+	expires, err := gittemplate.ReadExpiry(fake.Config["global"]["commit.template"])
+	require.NoError(t, err)
+	assert.True(t, expires.IsZero())
+
+	// Ending the session is a change of its own, which can be undone
+	require.NoError(t, undoCmd.RunE(undoCmd, nil))
+	assert.Empty(t, activeEmails(t, gittemplate.ScopeGlobal))
+	require.NoError(t, undoCmd.RunE(undoCmd, nil))
+	assert.Equal(t, []string{"jane@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+
+	history, err := journal.Load()
+	require.NoError(t, err)
+	require.Len(t, history.Entries, 2)
+	assert.True(t, strings.HasPrefix(history.Entries[0].Command, "session ended at "))
+}
+
+func TestSessionExpiryIsKept(t *testing.T) {
+	fake := setupFakeGit(t)
+
+	end := time.Now().Add(time.Hour).Truncate(time.Second)
+	jane := config.Config.CoAuthorsMap["jane"]
+	require.NoError(t, gittemplate.UpdateTemplateUntil(gittemplate.ScopeGlobal, []models.CoAuthor{jane}, end))
+
+	require.NoError(t, addCoAuthor(addCmd, []string{"john"}))
+
+	expires, err := gittemplate.ReadExpiry(fake.Config["global"]["commit.template"])
+	require.NoError(t, err)
+	assert.True(t, end.Equal(expires))
+}
+
+func TestAddReportsChangesAsJSON(t *testing.T) {
+	setupFakeGit(t)
+	viper.Set("output", "json")
+	activateAliases(t, gittemplate.ScopeGlobal, []string{"john"})
+
+	var buf bytes.Buffer
+	previous := outputWriter
+	outputWriter = &buf
+	t.Cleanup(func() { outputWriter = previous })
+
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane", "john", "me"}))
+
+	var report changeReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+
+	assert.Equal(t, []coAuthorRecord{
+		{Index: 0, Alias: "jane", Name: "Jane Smith", Email: "jane@example.com", Active: true, Trailer: "Co-authored-by"},
+	}, report.Added)
+	assert.Empty(t, report.Removed)
+	require.Len(t, report.Skipped, 2)
+	assert.Equal(t, "john", report.Skipped[0].Alias)
+	assert.Equal(t, "already active", report.Skipped[0].Reason)
+	assert.Equal(t, "me", report.Skipped[1].Alias)
+	assert.Equal(t, "yourself", report.Skipped[1].Reason)
+	assert.Len(t, report.Active, 2)
+}
+
+func TestConfigValidate(t *testing.T) {
+	setupFakeGit(t)
+	viper.Set("output", "plain")
+
+	var buf bytes.Buffer
+	previous := outputWriter
+	outputWriter = &buf
+	t.Cleanup(func() { outputWriter = previous })
+
+	require.NoError(t, configValidateCmd.RunE(configValidateCmd, nil))
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte(`{
+  "coauthors": {
+    "jane": {"name": "Jane Smith", "email": "Jane <jane@example.com>"},
+    "john": {"name": "John Doe", "email": "jane@example.com"}
+  }
+}`), 0644))
+	assert.EqualError(t, configValidateCmd.RunE(configValidateCmd, nil), "found 1 errors in the roster")
+	assert.Equal(t, config.ConfigPath+":3:36: error: invalid co-author 'jane': email must be a plain address without name and angle brackets, use jane@example.com\n", buf.String())
+
+	buf.Reset()
+	viper.Set("output", "json")
+	require.NoError(t, os.WriteFile(config.ConfigPath, []byte(`{
+  "coauthors": {
+    "jane": {"name": "Jane Smith", "email": "jane@example.com"},
+    "john": {"name": "John Doe", "email": "JANE@example.com"}
+  }
+}`), 0644))
+	require.NoError(t, configValidateCmd.RunE(configValidateCmd, nil))
+
+	var report validateReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.True(t, report.Valid)
+	assert.Equal(t, 1, report.Warnings)
+	assert.Equal(t, []problemRecord{{
+		Severity: config.SeverityWarning,
+		Path:     config.ConfigPath,
+		Line:     4,
+		Column:   34,
+		Key:      "coauthors.john.email",
+		Message:  "email 'JANE@example.com' of 'john' is also used by 'jane', who gets the credit for it",
+	}}, report.Problems)
+}
+
+// doctorStatuses runs pair doctor and returns the status of every check by name
+func doctorStatuses(t *testing.T) (map[string]string, error) {
+	t.Helper()
+	viper.Set("output", "json")
+
+	var buf bytes.Buffer
+	previous := outputWriter
+	outputWriter = &buf
+	t.Cleanup(func() { outputWriter = previous })
+
+	err := doctorCmd.RunE(doctorCmd, nil)
+
+	var report doctorReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	statuses := map[string]string{}
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	assert.Equal(t, err == nil, report.Healthy)
+	return statuses, err
+}
+
+func TestDoctor(t *testing.T) {
+	fake := setupFakeGit(t)
+	fake.Handlers["--version"] = func(git.Command) (string, error) { return "git version 2.45.0\n", nil }
+	activateAliases(t, gittemplate.ScopeGlobal, []string{"jane"})
+
+	statuses, err := doctorStatuses(t)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"git": "pass", "settings": "pass", "user": "pass", "roster": "pass", "template": "pass", "hook": "pass",
+	}, statuses)
+
+	// A local template wins over the global one pair writes to
+	own := filepath.Join(t.TempDir(), "template")
+	require.NoError(t, os.WriteFile(own, []byte("[TICKET-]\n"), 0644))
+	fake.Config["local"]["commit.template"] = own
+
+	// A hook of someone else may replace the template
+	hooks := filepath.Join(fake.GitDir, "hooks")
+	require.NoError(t, os.MkdirAll(hooks, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks, "prepare-commit-msg"), []byte("#!/bin/sh\n"), 0755))
+
+	statuses, err = doctorStatuses(t)
+	require.NoError(t, err)
+	assert.Equal(t, "warn", statuses["template"])
+	assert.Equal(t, "warn", statuses["hook"])
+
+	// Templates that are gone and a missing user.email break committing
+	fake.Config["local"]["commit.template"] = filepath.Join(t.TempDir(), "missing")
+	delete(fake.Config["global"], "user.email")
+
+	statuses, err = doctorStatuses(t)
+	assert.EqualError(t, err, "2 of 6 checks failed")
+	assert.Equal(t, "fail", statuses["template"])
+	assert.Equal(t, "fail", statuses["user"])
+}
+
+func TestUndoRedo(t *testing.T) {
+	fake := setupFakeGit(t)
+
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane", "john"}))
+	require.NoError(t, removeCoAuthor(removeCmd, []string{"john"}))
+	require.NoError(t, clearCoAuthors(clearCmd, nil))
+	assert.Empty(t, activeEmails(t, gittemplate.ScopeGlobal))
+
+	require.NoError(t, undoCmd.RunE(undoCmd, nil))
+	assert.Equal(t, []string{"jane@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+	require.NoError(t, undoCmd.RunE(undoCmd, nil))
+	assert.Equal(t, []string{"jane@example.com", "john@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+
+	require.NoError(t, redoCmd.RunE(redoCmd, nil))
+	assert.Equal(t, []string{"jane@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+
+	// Undoing everything leaves no template behind
+	require.NoError(t, undoCmd.RunE(undoCmd, nil))
+	require.NoError(t, undoCmd.RunE(undoCmd, nil))
+	assert.NotContains(t, fake.Config["global"], "commit.template")
+	assert.EqualError(t, undoCmd.RunE(undoCmd, nil), "nothing to undo in global scope")
+
+	// Changes of the local scope are undone separately
+	viper.Set("scope", "local")
+	require.NoError(t, addCoAuthor(addCmd, []string{"sam"}))
+	assert.EqualError(t, redoCmd.RunE(redoCmd, nil), "nothing to redo in local scope")
+	viper.Set("scope", "global")
+
+	// A new change discards what could be redone in its scope
+	require.NoError(t, addCoAuthor(addCmd, []string{"john"}))
+	assert.EqualError(t, redoCmd.RunE(redoCmd, nil), "nothing to redo in global scope")
+
+	viper.Set("output", "json")
+	var buf bytes.Buffer
+	previous := outputWriter
+	outputWriter = &buf
+	t.Cleanup(func() { outputWriter = previous })
+	require.NoError(t, historyCmd.RunE(historyCmd, nil))
+
+	var records []historyRecord
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+	var changes []string
+	for _, record := range records {
+		changes = append(changes, fmt.Sprintf("%s %s %t", record.Scope, record.change(), record.Undone))
+	}
+	assert.Equal(t, []string{
+		"global +john@example.com false",
+		"local +sam@example.com false",
+	}, changes)
+	assert.Equal(t, fake.GitDir, records[1].Repository)
+}
+
+func TestBrokenHistoryOnlyWarns(t *testing.T) {
+	fake := setupFakeGit(t)
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	history := filepath.Join(home, ".config", "pair", "history.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(history), 0755))
+	require.NoError(t, os.WriteFile(history, []byte("{"), 0644))
+
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane"}))
+	assert.Equal(t, []string{"jane@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+
+	// Rotating the mob from the hook does not abort the commit
+	require.NoError(t, mobStartCmd.RunE(mobStartCmd, []string{"jane", "john", "sam"}))
+	setMobTimer(t, 10*time.Minute, 1)
+	fake.Handlers["interpret-trailers"] = func(git.Command) (string, error) { return "", nil }
+	message := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(message, []byte("Fix the build\n"), 0644))
+	require.NoError(t, hookRunCmd.RunE(hookRunCmd, []string{message}))
+	assert.Equal(t, []string{"sam@example.com", "jane@example.com"}, activeEmails(t, gittemplate.ScopeGlobal))
+
+	// Undo cannot work without the history and says so
+	assert.ErrorContains(t, undoCmd.RunE(undoCmd, nil), "could not parse history")
+	data, err := os.ReadFile(history)
+	require.NoError(t, err)
+	assert.Equal(t, "{", string(data))
+}
+
+// setTrailerFlag sets --as of cmd for the duration of the test
+func setTrailerFlag(t *testing.T, cmd *cobra.Command, value string) {
+	t.Helper()
+	require.NoError(t, cmd.Flags().Set("as", value))
+	t.Cleanup(func() {
+		_ = cmd.Flags().Set("as", "")
+		cmd.Flags().Lookup("as").Changed = false
+	})
+}
+
+func TestOtherTrailers(t *testing.T) {
+	fake := setupFakeGit(t)
+	activateAliases(t, gittemplate.ScopeGlobal, []string{"jane"})
+
+	// The same person can be credited with several trailers, also yourself
+	setTrailerFlag(t, addCmd, "reviewed-by")
+	require.NoError(t, addCoAuthor(addCmd, []string{"jane", "me"}))
+	assert.EqualError(t, addCoAuthor(addCmd, []string{"jane"}), "no co-authors were added")
+
+	data, err := os.ReadFile(fake.Config["global"]["commit.template"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `Co-authored-by: Jane Smith <jane@example.com>
+Reviewed-by: Jane Smith <jane@example.com>
+Reviewed-by: Me Myself <me@example.com>
+`)
+
+	templatePath := fake.Config["global"]["commit.template"]
+	active, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	require.NoError(t, err)
+	require.Len(t, active, 3)
+	assert.True(t, active[0].IsCoAuthor())
+	assert.Equal(t, "Reviewed-by", active[1].TrailerKey)
+
+	// Removing with --as keeps the other trailers of the person
+	setTrailerFlag(t, removeCmd, "Reviewed-By")
+	require.NoError(t, removeCoAuthor(removeCmd, []string{"jane"}))
+	active, err = gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	require.NoError(t, err)
+	require.Len(t, active, 2)
+	assert.Equal(t, "Co-authored-by", active[0].TrailerKey)
+	assert.Equal(t, "Me Myself", active[1].Name)
+
+	setTrailerFlag(t, addCmd, "acked-by")
+	assert.ErrorContains(t, addCoAuthor(addCmd, []string{"john"}), "unknown trailer 'acked-by'")
+
+	// Custom trailer keys come from the roster or the settings
+	viper.Set("trailers", []string{"Acked-by"})
+	require.NoError(t, addCoAuthor(addCmd, []string{"john"}))
+	assert.Contains(t, activeEmails(t, gittemplate.ScopeGlobal), "john@example.com")
+}
+
+func TestCompletions(t *testing.T) {
+	setupFakeGit(t)
+	activateAliases(t, gittemplate.ScopeGlobal, []string{"john"})
+
+	completions, directive := completeRoster(true)(addCmd, []string{"sam"}, "")
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+	assert.Equal(t, []string{
+		"jane\tJane Smith <jane@example.com>",
+		"me\tMe Myself <me@example.com>",
+		"squad\tgroup",
+	}, completions)
+
+	completions, _ = completeActive(removeCmd, nil, "")
+	assert.Equal(t, []string{"john\tJohn Doe <john@example.com>", "squad\tgroup"}, completions)
+
+	completions, _ = completeActive(removeCmd, []string{"john"}, "")
+	assert.Empty(t, completions)
+}
+
+// setMobTimer lets the rotation interval of the running mob elapse the given number of times
+func setMobTimer(t *testing.T, interval time.Duration, elapsed int) {
+	t.Helper()
+	state, err := mob.Load()
+	require.NoError(t, err)
+	state.Interval = interval.String()
+	state.RotatedAt = time.Now().Add(-time.Duration(elapsed)*interval - time.Minute)
+	require.NoError(t, mob.Save(state))
+}
+
+func TestMobStaysInItsRepository(t *testing.T) {
+	fake := setupFakeGit(t)
+	repository := fake.GitDir
+	viper.Set("scope", "local")
+
+	require.NoError(t, mobStartCmd.RunE(mobStartCmd, []string{"jane", "john", "sam"}))
+	assert.Equal(t, []string{"john@example.com", "sam@example.com"}, activeEmails(t, gittemplate.ScopeLocal))
+
+	state, err := mob.Load()
+	require.NoError(t, err)
+	assert.Equal(t, repository, state.Repository)
+
+	require.NoError(t, mobNextCmd.RunE(mobNextCmd, nil))
+	assert.Equal(t, []string{"sam@example.com", "jane@example.com"}, activeEmails(t, gittemplate.ScopeLocal))
+
+	// Another repository can neither rotate nor stop the mob
+	fake.GitDir = filepath.Join(t.TempDir(), ".git")
+	require.NoError(t, os.MkdirAll(fake.GitDir, 0755))
+	assert.ErrorContains(t, mobNextCmd.RunE(mobNextCmd, nil), "run this command there")
+	assert.ErrorContains(t, mobStopCmd.RunE(mobStopCmd, nil), "run this command there")
+
+	// Elapsed rotations are left for the mob's repository
+	setMobTimer(t, 10*time.Minute, 1)
+	_, _, err = loadActiveCoAuthors(gittemplate.ScopeGlobal)
+	require.NoError(t, err)
+	state, err = mob.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "john", state.Driver().Alias)
+
+	// and applied by any command reading the co-authors there
+	fake.GitDir = repository
+	_, _, err = loadActiveCoAuthors(gittemplate.ScopeLocal)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jane@example.com", "john@example.com"}, activeEmails(t, gittemplate.ScopeLocal))
+
+	require.NoError(t, mobStopCmd.RunE(mobStopCmd, nil))
+	assert.Empty(t, activeEmails(t, gittemplate.ScopeLocal))
+	state, err = mob.Load()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestMobKeepsOtherTrailers(t *testing.T) {
+	fake := setupFakeGit(t)
+	require.NoError(t, mobStartCmd.RunE(mobStartCmd, []string{"jane", "john", "sam"}))
+
+	setTrailerFlag(t, addCmd, "reviewed-by")
+	require.NoError(t, addCoAuthor(addCmd, []string{"me"}))
+
+	// The hook rotates with the roster it loads itself
+	setMobTimer(t, 10*time.Minute, 1)
+	config.Config = models.Config{}
+	fake.Handlers["interpret-trailers"] = func(git.Command) (string, error) { return "", nil }
+	message := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(message, []byte("Fix the build\n"), 0644))
+	require.NoError(t, hookRunCmd.RunE(hookRunCmd, []string{message}))
+
+	data, err := os.ReadFile(fake.Config["global"]["commit.template"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `Co-authored-by: Sam Johnson <sam@example.com>
+Co-authored-by: Jane Smith <jane@example.com>
+Reviewed-by: Me Myself <me@example.com>
+`)
+
+	require.NoError(t, mobNextCmd.RunE(mobNextCmd, nil))
+	data, err = os.ReadFile(fake.Config["global"]["commit.template"])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Reviewed-by: Me Myself <me@example.com>\n")
+}
+
+func TestHookIgnoresBrokenMobState(t *testing.T) {
+	fake := setupFakeGit(t)
+	require.NoError(t, mobStartCmd.RunE(mobStartCmd, []string{"jane", "john"}))
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".config", "pair", "mob.json"), []byte("{"), 0644))
+
+	var trailers []string
+	fake.Handlers["interpret-trailers"] = func(cmd git.Command) (string, error) {
+		for i, arg := range cmd.Args {
+			if arg == "--trailer" {
+				trailers = append(trailers, cmd.Args[i+1])
+			}
+		}
+		return "", nil
+	}
+	message := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(message, []byte("Fix the build\n"), 0644))
+	require.NoError(t, hookRunCmd.RunE(hookRunCmd, []string{message}))
+	assert.Equal(t, []string{"Co-authored-by: John Doe <john@example.com>"}, trailers)
+}
+
+func TestMobOfRemovedRepositoryCanBeStopped(t *testing.T) {
+	fake := setupFakeGit(t)
+	viper.Set("scope", "local")
+	require.NoError(t, mobStartCmd.RunE(mobStartCmd, []string{"jane", "john"}))
+
+	require.NoError(t, os.RemoveAll(fake.GitDir))
+	fake.GitDir = ""
+	require.NoError(t, mobStopCmd.RunE(mobStopCmd, nil))
+
+	state, err := mob.Load()
+	require.NoError(t, err)
+	assert.Nil(t, state)
+}
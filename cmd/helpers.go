@@ -6,12 +6,19 @@ import (
 	"github.com/jedib0t/go-pretty/v6/text"
 
 	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/identity"
 	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
 	"os"
-	exec "os/exec"
 	"strings"
+	"time"
 )
 
+// sessionTimeLayout is used to display and parse the end of pairing sessions
+const sessionTimeLayout = "2006-01-02 15:04"
+
 // findCoAuthorByAliasOrIndex finds a co-author by alias or index
 func findCoAuthorByAliasOrIndex(identifier string) (models.CoAuthor, int, error) {
 	// Try to find by alias first
@@ -33,28 +40,197 @@ func findCoAuthorByAliasOrIndex(identifier string) (models.CoAuthor, int, error)
 	return models.CoAuthor{}, -1, fmt.Errorf("no co-author found with alias '%s'", identifier)
 }
 
+// resolveCoAuthors resolves a group name, alias or index to the co-authors it refers to
+func resolveCoAuthors(identifier string) ([]models.CoAuthor, error) {
+	if members, exists := config.Config.Groups[identifier]; exists {
+		coAuthors := make([]models.CoAuthor, 0, len(members))
+		for _, alias := range members {
+			coAuthor, _, err := findCoAuthorByAliasOrIndex(alias)
+			if err != nil {
+				return nil, fmt.Errorf("group '%s': %w", identifier, err)
+			}
+			coAuthors = append(coAuthors, coAuthor)
+		}
+		return coAuthors, nil
+	}
+
+	coAuthor, _, err := findCoAuthorByAliasOrIndex(identifier)
+	if err != nil {
+		return nil, err
+	}
+	return []models.CoAuthor{coAuthor}, nil
+}
+
+// trailerKeyFlag returns the trailer selected with --as in its configured
+// spelling, Co-authored-by if the flag was not given
+func trailerKeyFlag(cmd *cobra.Command) (string, error) {
+	name, _ := cmd.Flags().GetString("as")
+	if name == "" {
+		return models.DefaultTrailerKey, nil
+	}
+	return config.ResolveTrailerKey(name)
+}
+
+// sameCredit reports whether two active entries credit the same person with the same trailer
+func sameCredit(matcher *identity.Matcher, a, b models.CoAuthor) bool {
+	return strings.EqualFold(a.Key(), b.Key()) && matcher.Same(a.Email, b.Email)
+}
+
+// trailerSuffix names the trailer of co-authors not credited with Co-authored-by, for messages
+func trailerSuffix(author models.CoAuthor) string {
+	if author.IsCoAuthor() {
+		return ""
+	}
+	return " as " + author.Key()
+}
+
+// currentScope returns the commit template scope selected via --scope or the settings file
+func currentScope() (gittemplate.Scope, error) {
+	return gittemplate.ParseScope(config.GetScope())
+}
+
+// loadActiveCoAuthors returns the template of the given scope and its active co-authors.
+// An expired pairing session is cleared first and yields no co-authors.
+func loadActiveCoAuthors(scope gittemplate.Scope) (string, []models.CoAuthor, error) {
+	// Hand the keyboard over first, so a running mob credits the current navigators
+	if _, err := syncMob(); err != nil {
+		return "", nil, err
+	}
+
+	expired, err := expireSession(scope)
+	if err != nil {
+		return "", nil, err
+	}
+	if !expired.IsZero() {
+		notef("Pairing session ended at %s, co-authors have been cleared\n", expired.Local().Format(sessionTimeLayout))
+	}
+
+	templatePath, err := gittemplate.GetCurrentTemplate(scope)
+	if err != nil {
+		return "", nil, err
+	}
+
+	activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	if err != nil {
+		return "", nil, err
+	}
+
+	return templatePath, activeCoAuthors, nil
+}
+
+// updateTemplate writes the active co-authors to the template of the given scope.
+// A new time-boxed session is started if --for or --until was given, otherwise the
+// end of the current session is kept.
+func updateTemplate(cmd *cobra.Command, scope gittemplate.Scope, activeCoAuthors []models.CoAuthor) error {
+	expires, err := sessionExpiry(cmd, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if expires.IsZero() {
+		return writeTemplate(scope, activeCoAuthors)
+	}
+
+	if err := journaled(scope, func() error {
+		return gittemplate.UpdateTemplateUntil(scope, withRosterEmails(activeCoAuthors), expires)
+	}); err != nil {
+		return err
+	}
+	notef("Pairing session ends at %s\n", expires.Local().Format(sessionTimeLayout))
+	return nil
+}
+
+// validateSessionFlags rejects invalid --for and --until values before anything is changed
+func validateSessionFlags(cmd *cobra.Command, args []string) error {
+	_, err := sessionExpiry(cmd, time.Now())
+	return err
+}
+
+// sessionExpiry returns the end of the session requested with --for or --until,
+// or the zero time if neither flag was given
+func sessionExpiry(cmd *cobra.Command, now time.Time) (time.Time, error) {
+	if flag := cmd.Flags().Lookup("for"); flag != nil && flag.Changed {
+		duration, err := cmd.Flags().GetDuration("for")
+		if err != nil {
+			return time.Time{}, err
+		}
+		if duration <= 0 {
+			return time.Time{}, fmt.Errorf("--for must be a positive duration, got %s", duration)
+		}
+		return now.Add(duration), nil
+	}
+
+	if flag := cmd.Flags().Lookup("until"); flag != nil && flag.Changed {
+		return parseUntil(flag.Value.String(), now)
+	}
+
+	return time.Time{}, nil
+}
+
+// parseUntil parses the value of --until, accepting a time of day ("18:00"),
+// a local date and time ("2006-01-02 15:04") or an RFC 3339 timestamp
+func parseUntil(value string, now time.Time) (time.Time, error) {
+	var until time.Time
+	if clock, err := time.ParseInLocation("15:04", value, now.Location()); err == nil {
+		until = time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	} else if local, err := time.ParseInLocation(sessionTimeLayout, value, now.Location()); err == nil {
+		until = local
+	} else if timestamp, err := time.Parse(time.RFC3339, value); err == nil {
+		until = timestamp
+	} else {
+		return time.Time{}, fmt.Errorf("invalid --until value '%s' (use 18:00, \"2006-01-02 18:00\" or RFC 3339)", value)
+	}
+
+	if !until.After(now) {
+		return time.Time{}, fmt.Errorf("--until %s is in the past", value)
+	}
+	return until, nil
+}
+
+// withRosterEmails credits every co-author found in the roster with the primary
+// email of their entry and keeps the further ones, so that the template of a
+// repository can select among them
+func withRosterEmails(activeCoAuthors []models.CoAuthor) []models.CoAuthor {
+	matcher := newMatcher()
+	credited := make([]models.CoAuthor, 0, len(activeCoAuthors))
+	for _, author := range activeCoAuthors {
+		if len(author.Emails) == 0 {
+			if entry, ok := matcher.Lookup(author.Email); ok {
+				author.Email, author.Emails = entry.Email, entry.Emails
+			}
+		}
+		credited = append(credited, author)
+	}
+	return credited
+}
+
+// newMatcher returns a matcher for the loaded roster, telling whether two
+// addresses belong to the same person
+func newMatcher() *identity.Matcher {
+	return identity.NewMatcher(config.Config.CoAuthors)
+}
+
 // getGitUserInfo retrieves the current git user.name and user.email
 func getGitUserInfo() (name string, email string, err error) {
 	// Get user name
-	cmdName := exec.Command("git", "config", "--get", "user.name")
-	nameBytes, err := cmdName.Output()
+	nameOutput, err := git.Run("config", "--get", "user.name")
 	if err != nil {
 		return "", "", fmt.Errorf("failed to get git user.name: %w", err)
 	}
-	name = strings.TrimSpace(string(nameBytes))
+	name = strings.TrimSpace(nameOutput)
 
 	// Get user email
-	cmdEmail := exec.Command("git", "config", "--get", "user.email")
-	emailBytes, err := cmdEmail.Output()
+	emailOutput, err := git.Run("config", "--get", "user.email")
 	if err != nil {
 		return name, "", fmt.Errorf("failed to get git user.email: %w", err)
 	}
-	email = strings.TrimSpace(string(emailBytes))
+	email = strings.TrimSpace(emailOutput)
 
 	return name, email, nil
 }
 
-func renderCoAuthorTable(title string, authors []models.CoAuthor, getAlias func(author models.CoAuthor) string) {
+// newTable returns a table writing to stdout, styled according to the color settings
+func newTable(title string) table.Writer {
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 
@@ -85,7 +261,24 @@ func renderCoAuthorTable(title string, authors []models.CoAuthor, getAlias func(
 		fmt.Println(title)
 	}
 
-	t.AppendHeader(table.Row{"#", "Alias", "Name", "Email"})
+	return t
+}
+
+func renderCoAuthorTable(title string, authors []models.CoAuthor, getAlias func(author models.CoAuthor) string) {
+	// Only show the trailer if someone is credited with another one than Co-authored-by
+	showTrailer := false
+	for _, author := range authors {
+		if !author.IsCoAuthor() {
+			showTrailer = true
+		}
+	}
+
+	t := newTable(title)
+	header := table.Row{"#", "Alias", "Name", "Email"}
+	if showTrailer {
+		header = append(header, "Trailer")
+	}
+	t.AppendHeader(header)
 
 	for i, author := range authors {
 		alias := ""
@@ -93,7 +286,37 @@ func renderCoAuthorTable(title string, authors []models.CoAuthor, getAlias func(
 			alias = getAlias(author)
 		}
 
-		t.AppendRow([]interface{}{i, alias, author.Name, author.Email})
+		row := []interface{}{i, alias, author.Name, emailCell(author)}
+		if showTrailer {
+			row = append(row, author.Key())
+		}
+		t.AppendRow(row)
+	}
+	t.Render()
+}
+
+// emailCell lists every address of the co-author on its own line, followed by
+// the rules selecting it
+func emailCell(author models.CoAuthor) string {
+	lines := []string{author.Email}
+	for _, email := range author.Emails {
+		rules := append(append([]string{}, email.Hosts...), email.Paths...)
+		if len(rules) == 0 {
+			lines = append(lines, email.Address)
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%s)", email.Address, strings.Join(rules, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderGroupTable prints the configured groups with their member aliases
+func renderGroupTable(title string, groupNames []string, groups map[string][]string) {
+	t := newTable(title)
+	t.AppendHeader(table.Row{"Group", "Members"})
+
+	for _, name := range groupNames {
+		t.AppendRow([]interface{}{name, strings.Join(groups[name], ", ")})
 	}
 	t.Render()
 }
@@ -2,9 +2,11 @@ package commands
 
 import (
 	"testing"
+	"time"
 
 	"github.com/philippeckel/pair/internal/config"
 	"github.com/philippeckel/pair/internal/models"
+	"github.com/philippeckel/pair/internal/stats"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -115,3 +117,77 @@ func TestFindCoAuthorByAliasOrIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUntil(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		value          string
+		expect         time.Time
+		expectErrMatch string
+	}{
+		{
+			name:   "Time of day",
+			value:  "18:00",
+			expect: time.Date(2025, 3, 14, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "Date and time",
+			value:  "2025-03-15 09:15",
+			expect: time.Date(2025, 3, 15, 9, 15, 0, 0, time.UTC),
+		},
+		{
+			name:   "RFC 3339",
+			value:  "2025-03-14T12:00:00Z",
+			expect: time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "In the past",
+			value:          "09:00",
+			expectErrMatch: "is in the past",
+		},
+		{
+			name:           "Invalid value",
+			value:          "tonight",
+			expectErrMatch: "invalid --until value",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			until, err := parseUntil(tc.value, now)
+			if tc.expectErrMatch != "" {
+				assert.ErrorContains(t, err, tc.expectErrMatch)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tc.expect.Equal(until), "expected %s, got %s", tc.expect, until)
+		})
+	}
+}
+
+func TestSuggestPartners(t *testing.T) {
+	me := models.CoAuthor{Alias: "me", Name: "Me", Email: "me@example.com"}
+	jane := models.CoAuthor{Alias: "jane", Name: "Jane Smith", Email: "jane@example.com"}
+	john := models.CoAuthor{Alias: "john", Name: "John Doe", Email: "john@example.com"}
+	sam := models.CoAuthor{Alias: "sam", Name: "Sam Johnson", Email: "sam@example.com"}
+	kim := models.CoAuthor{Alias: "kim", Name: "Kim Lee", Email: "kim@example.com"}
+	config.Config = models.Config{CoAuthors: []models.CoAuthor{me, jane, john, sam, kim}}
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	report := stats.Build([]stats.Commit{
+		{Date: day(4), Author: me, CoAuthors: []models.CoAuthor{jane}},
+		{Date: day(3), Author: john, CoAuthors: []models.CoAuthor{{Name: "Me", Email: "ME@example.com"}}},
+		{Date: day(2), Author: me, CoAuthors: []models.CoAuthor{john}},
+		{Date: day(1), Author: jane, CoAuthors: []models.CoAuthor{sam}},
+	})
+
+	var aliases []string
+	for _, suggestion := range suggestPartners(report, "me@example.com") {
+		aliases = append(aliases, suggestion.Alias)
+	}
+
+	// Never paired in roster order, then least recently paired
+	assert.Equal(t, []string{"sam", "kim", "john", "jane"}, aliases)
+}
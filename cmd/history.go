@@ -0,0 +1,404 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/identity"
+	"github.com/philippeckel/pair/internal/journal"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// commandLine is recorded with every change of the active co-authors, set by Execute
+var commandLine = "pair"
+
+var historyLimit int
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Restore the co-authors before the last change",
+	Long: `Restore the active co-authors as they were before the last change in the selected
+scope, e.g. a mistaken "pair clear" or "pair unselect". Run it again to go further back.
+Local and worktree scopes only undo changes made in the current repository.`,
+	Example: "pair undo\n" +
+		"pair undo --scope local",
+	Args: cobra.NoArgs,
+	// An empty history is no usage error
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return replay(true)
+	},
+}
+
+var redoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Apply the last undone change of the co-authors again",
+	Long: `Apply the change reverted by the last "pair undo" in the selected scope again.
+Any other change of the co-authors discards what can be redone.`,
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return replay(false)
+	},
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the recent changes of the active co-authors",
+	Long: `Show the recent changes of the active co-authors in all scopes, newest first, with
+the command that made them. Changes reverted by "pair undo" are marked as undone.`,
+	Example: "pair history\n" +
+		"pair history --limit 0 --output json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		history, err := journal.Load()
+		if err != nil {
+			return err
+		}
+
+		entries := history.Entries
+		if historyLimit > 0 && len(entries) > historyLimit {
+			entries = entries[len(entries)-historyLimit:]
+		}
+
+		// Newest first
+		records := make([]historyRecord, 0, len(entries))
+		for i := len(entries) - 1; i >= 0; i-- {
+			records = append(records, newHistoryRecord(entries[i]))
+		}
+		return printHistory(records)
+	},
+}
+
+// journalTarget returns the scope and repository changes of the scope are recorded for
+func journalTarget(scope gittemplate.Scope) (string, error) {
+	if scope == gittemplate.ScopeGlobal {
+		return "", nil
+	}
+	return scope.GitDir()
+}
+
+// snapshotOf reads the active co-authors of the scope as they are now
+func snapshotOf(scope gittemplate.Scope) (journal.Snapshot, error) {
+	snapshot := journal.Snapshot{CoAuthors: []journal.Member{}}
+
+	templatePath, err := gittemplate.GetCurrentTemplate(scope)
+	if err != nil || templatePath == "" {
+		return snapshot, err
+	}
+
+	activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	if err != nil {
+		return snapshot, err
+	}
+	for _, author := range activeCoAuthors {
+		snapshot.CoAuthors = append(snapshot.CoAuthors, journal.NewMember(author))
+	}
+
+	expires, err := gittemplate.ReadExpiry(templatePath)
+	if err != nil {
+		return snapshot, err
+	}
+	if !expires.IsZero() {
+		snapshot.Expires = &expires
+	}
+	return snapshot, nil
+}
+
+// journaled runs change, which writes or clears the template of scope, and
+// records the co-authors before and after in the journal for pair undo.
+// Nothing is recorded if the co-authors stay the same or the template could
+// not be read before, as there would be nothing to restore. The change has
+// been made by then, so a journal that cannot be recorded in only gets a
+// warning instead of failing the command or the commit hook.
+func journaled(scope gittemplate.Scope, change func() error) error {
+	return journaledAs(scope, commandLine, change)
+}
+
+// journaledAs works like journaled but records the change as made by command
+func journaledAs(scope gittemplate.Scope, command string, change func() error) error {
+	before, beforeErr := snapshotOf(scope)
+
+	if err := change(); err != nil {
+		return err
+	}
+	if beforeErr != nil {
+		return nil
+	}
+
+	after, err := snapshotOf(scope)
+	if err != nil || after.Equal(before) {
+		return nil
+	}
+
+	repository, err := journalTarget(scope)
+	if err == nil {
+		err = journal.Update(func(history *journal.Journal) error {
+			history.Record(journal.Entry{
+				Time:       time.Now(),
+				Command:    command,
+				Scope:      string(scope),
+				Repository: repository,
+				Before:     before,
+				After:      after,
+			})
+			return nil
+		})
+	}
+	if err != nil {
+		notef("Warning: co-authors changed, but could not record it for pair undo: %v\n", err)
+	}
+	return nil
+}
+
+// writeTemplate credits the co-authors in the template of scope, keeping the end
+// of the session, and records the change
+func writeTemplate(scope gittemplate.Scope, activeCoAuthors []models.CoAuthor) error {
+	return journaled(scope, func() error {
+		return gittemplate.UpdateTemplate(scope, withRosterEmails(activeCoAuthors))
+	})
+}
+
+// clearTemplate removes pair's template from scope and records the change
+func clearTemplate(scope gittemplate.Scope) error {
+	return journaled(scope, func() error {
+		return gittemplate.ClearTemplate(scope)
+	})
+}
+
+// expireSession clears the template of scope if its session has ended and
+// records the change, so that pair undo can bring the co-authors back. It
+// returns when the session ended, or the zero time if nothing was cleared.
+func expireSession(scope gittemplate.Scope) (time.Time, error) {
+	expired, err := gittemplate.SessionEnded(scope)
+	if err != nil || expired.IsZero() {
+		return time.Time{}, err
+	}
+
+	command := "session ended at " + expired.Local().Format(sessionTimeLayout)
+	if err := journaledAs(scope, command, func() error {
+		return gittemplate.ClearTemplate(scope)
+	}); err != nil {
+		return time.Time{}, err
+	}
+	return expired, nil
+}
+
+// restore makes the snapshot the active co-authors of scope again. A session
+// that has ended since is restored without an end.
+func restore(scope gittemplate.Scope, snapshot journal.Snapshot) error {
+	if len(snapshot.CoAuthors) == 0 {
+		return gittemplate.ClearTemplate(scope)
+	}
+
+	var expires time.Time
+	if snapshot.Expires != nil && time.Now().Before(*snapshot.Expires) {
+		expires = *snapshot.Expires
+	}
+	return gittemplate.UpdateTemplateUntil(scope, members(snapshot), expires)
+}
+
+// replay undoes the last change of the selected scope, or redoes the last
+// undone one, and reports how the active co-authors changed
+func replay(undo bool) error {
+	scope, err := currentScope()
+	if err != nil {
+		return err
+	}
+	repository, err := journalTarget(scope)
+	if err != nil {
+		return err
+	}
+
+	action, verb := "redo", "Redid"
+	if undo {
+		action, verb = "undo", "Undid"
+	}
+
+	var entry journal.Entry
+	var target, current journal.Snapshot
+	err = journal.Update(func(history *journal.Journal) error {
+		found := history.NextUndone(string(scope), repository)
+		if undo {
+			found = history.LastDone(string(scope), repository)
+		}
+		if found == nil {
+			return fmt.Errorf("nothing to %s in %s scope", action, scope)
+		}
+
+		target = found.After
+		if undo {
+			target = found.Before
+		}
+
+		var err error
+		if current, err = snapshotOf(scope); err != nil {
+			return err
+		}
+		if err := restore(scope, target); err != nil {
+			return err
+		}
+
+		found.Undone = undo
+		entry = *found
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	notef("%s '%s' from %s\n", verb, entry.Command, entry.Time.Local().Format(sessionTimeLayout))
+
+	// Aliases are only needed for the report
+	_ = config.LoadConfig()
+
+	report := newChangeReport()
+	matcher := newMatcher()
+	restored, previous := members(target), members(current)
+	for _, author := range restored {
+		if !containsCredit(matcher, previous, author) {
+			notef("Added co-author: %s <%s>%s\n", author.Name, author.Email, trailerSuffix(author))
+			report.added(author)
+		}
+	}
+	for _, author := range previous {
+		if !containsCredit(matcher, restored, author) {
+			notef("Removed co-author: %s <%s>%s\n", author.Name, author.Email, trailerSuffix(author))
+			report.removed(author)
+		}
+	}
+	report.setActive(restored)
+	return printReport(report)
+}
+
+// members returns the co-authors of a snapshot
+func members(snapshot journal.Snapshot) []models.CoAuthor {
+	coAuthors := make([]models.CoAuthor, 0, len(snapshot.CoAuthors))
+	for _, member := range snapshot.CoAuthors {
+		coAuthors = append(coAuthors, member.CoAuthor())
+	}
+	return coAuthors
+}
+
+// containsCredit reports whether list credits author with the same trailer
+func containsCredit(matcher *identity.Matcher, list []models.CoAuthor, author models.CoAuthor) bool {
+	for _, candidate := range list {
+		if sameCredit(matcher, candidate, author) {
+			return true
+		}
+	}
+	return false
+}
+
+// historyRecord is the machine-readable form of a journal entry
+type historyRecord struct {
+	ID         int              `json:"id" yaml:"id"`
+	Time       string           `json:"time" yaml:"time"`
+	Command    string           `json:"command" yaml:"command"`
+	Scope      string           `json:"scope" yaml:"scope"`
+	Repository string           `json:"repository,omitempty" yaml:"repository,omitempty"`
+	Undone     bool             `json:"undone" yaml:"undone"`
+	Before     journal.Snapshot `json:"before" yaml:"before"`
+	After      journal.Snapshot `json:"after" yaml:"after"`
+}
+
+func newHistoryRecord(entry journal.Entry) historyRecord {
+	return historyRecord{
+		ID:         entry.ID,
+		Time:       entry.Time.Format(time.RFC3339),
+		Command:    entry.Command,
+		Scope:      entry.Scope,
+		Repository: entry.Repository,
+		Undone:     entry.Undone,
+		Before:     entry.Before,
+		After:      entry.After,
+	}
+}
+
+// change summarizes the record as "+jane@example.com -john@example.com"
+func (r historyRecord) change() string {
+	var parts []string
+	for _, member := range r.After.CoAuthors {
+		if !containsMember(r.Before.CoAuthors, member) {
+			parts = append(parts, "+"+member.Email)
+		}
+	}
+	for _, member := range r.Before.CoAuthors {
+		if !containsMember(r.After.CoAuthors, member) {
+			parts = append(parts, "-"+member.Email)
+		}
+	}
+	if len(parts) == 0 {
+		return "session end changed"
+	}
+	return strings.Join(parts, " ")
+}
+
+// containsMember reports whether list credits the member with the same trailer
+func containsMember(list []journal.Member, member journal.Member) bool {
+	for _, candidate := range list {
+		if strings.EqualFold(candidate.Email, member.Email) && strings.EqualFold(candidate.Trailer, member.Trailer) {
+			return true
+		}
+	}
+	return false
+}
+
+// fields returns the record in the documented column order for csv and plain
+func (r historyRecord) fields() []string {
+	return []string{strconv.Itoa(r.ID), r.Time, r.Command, r.Scope, r.Repository, r.change(), strconv.FormatBool(r.Undone)}
+}
+
+// printHistory writes the history in the selected output format
+func printHistory(records []historyRecord) error {
+	switch outputFormat() {
+	case outputJSON, outputYAML:
+		return encode(records)
+	case outputCSV:
+		w := csv.NewWriter(outputWriter)
+		if err := w.Write([]string{"id", "time", "command", "scope", "repository", "change", "undone"}); err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := w.Write(record.fields()); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	case outputPlain:
+		for _, record := range records {
+			fmt.Fprintln(outputWriter, strings.Join(record.fields(), "\t"))
+		}
+		return nil
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No changes of the co-authors recorded yet")
+		return nil
+	}
+
+	t := newTable("History:")
+	t.AppendHeader(table.Row{"#", "Time", "Command", "Scope", "Change", "State"})
+	for _, record := range records {
+		undone := ""
+		if record.Undone {
+			undone = "undone"
+		}
+		when, _ := time.Parse(time.RFC3339, record.Time)
+		t.AppendRow(table.Row{record.ID, when.Local().Format(sessionTimeLayout), record.Command, record.Scope, record.change(), undone})
+	}
+	t.Render()
+	return nil
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "number of changes to show, 0 for all")
+}
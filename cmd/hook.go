@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/githook"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// hookCmd groups the commands managing the prepare-commit-msg hook
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "Manage the prepare-commit-msg hook",
+	Long: `Manage a prepare-commit-msg hook that appends the active co-authors to every
+commit message, including commits made with "git commit -m", from IDEs or by "git merge",
+which never see the commit template. Trailers already present are not added twice.`,
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the prepare-commit-msg hook in the current repository",
+	Long: `Install the prepare-commit-msg hook in the current repository, honoring core.hooksPath.
+An existing prepare-commit-msg hook is kept and still runs before pair.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := githook.Install()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed prepare-commit-msg hook at %s\n", path)
+		return nil
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the prepare-commit-msg hook from the current repository",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := githook.Uninstall()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed prepare-commit-msg hook from %s\n", path)
+		return nil
+	},
+}
+
+// hookRunCmd is invoked by the installed hook with the arguments git passes to it
+var hookRunCmd = &cobra.Command{
+	Use:    "run <message file> [source] [commit]",
+	Short:  "Append the active co-authors to a commit message file",
+	Args:   cobra.RangeArgs(1, 3),
+	Hidden: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The roster knows the custom trailer keys and selects the emails for this
+		// repository. A broken roster must never block a commit.
+		if err := config.LoadConfig(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "pair: using the trailers of the template as they are, the roster could not be loaded: %v\n", err)
+		}
+
+		// Hand the keyboard over if the mob timer has elapsed. A broken mob state
+		// must never block a commit either.
+		if _, err := syncMob(); err != nil {
+			fmt.Fprintf(os.Stderr, "pair: using the co-authors of the template, the mob could not be rotated: %v\n", err)
+		}
+
+		// Use the template git itself picks up in this repository
+		scope, templatePath, err := gittemplate.GetEffectiveTemplate()
+		if err != nil {
+			return err
+		}
+
+		// Credit nobody once a time-boxed session has ended
+		expires, err := gittemplate.ReadExpiry(templatePath)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !expires.IsZero() && !time.Now().Before(expires) {
+			if scope != "" {
+				_, err := expireSession(scope)
+				return err
+			}
+			return nil
+		}
+
+		activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+		if errors.Is(err, os.ErrNotExist) {
+			// A missing template must never block a commit either
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// The global template holds the primary emails. Git has already copied its
+		// trailers into the message, so the ones crediting another email for this
+		// repository are replaced instead of crediting the person twice.
+		credited := gittemplate.CreditedEmails(activeCoAuthors, config.Config.CoAuthors)
+		var replaced []models.CoAuthor
+		for i, author := range activeCoAuthors {
+			if credited[i].Email != author.Email {
+				replaced = append(replaced, author)
+			}
+		}
+		if err := githook.RemoveTrailers(args[0], replaced); err != nil {
+			return err
+		}
+
+		return githook.AppendTrailers(args[0], credited)
+	},
+}
+
+func init() {
+	hookCmd.AddCommand(hookInstallCmd, hookUninstallCmd, hookRunCmd)
+}
@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/ktr0731/go-fuzzyfinder"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/gitimport"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var importAll bool
+
+// importCmd groups the commands filling the roster from other sources
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import co-authors into the roster",
+}
+
+var importGitLogCmd = &cobra.Command{
+	Use:   "git-log",
+	Short: "Import authors and co-authors from the history of the current repository",
+	Long: `Find everyone who authored or co-authored a commit reachable from HEAD and is not
+in the roster yet, pick the ones to import with the fuzzy finder and add them to the
+roster. Identities are mapped through .mailmap and deduplicated by email. Aliases are
+derived from the names and never replace existing aliases.`,
+	Example: "pair import git-log\n" +
+		"pair import git-log --all --config .pair.json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Skip everyone known in any layer of the roster
+		if err := loadRosterOrEmpty(config.LoadConfig); err != nil {
+			return err
+		}
+
+		taken := append([]string{}, config.Config.GroupNames...)
+		for alias := range config.Config.CoAuthorsMap {
+			taken = append(taken, alias)
+		}
+
+		candidates, err := gitimport.Candidates(config.Config.CoAuthors, taken, config.TrailerKeys())
+		if err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			notef("Everyone in the history is already in the roster\n")
+			return printRecords([]coAuthorRecord{})
+		}
+
+		if !importAll {
+			if candidates, err = selectCandidates(candidates); err != nil {
+				return err
+			}
+		}
+
+		// Add them to the file changes are written to
+		if err := loadRosterOrEmpty(config.LoadConfigForWrite); err != nil {
+			return err
+		}
+
+		var imported []models.CoAuthor
+		for _, candidate := range candidates {
+			config.Config.CoAuthorsMap[candidate.Alias] = candidate.CoAuthor
+			config.Config.CoAuthors = append(config.Config.CoAuthors, candidate.CoAuthor)
+			imported = append(imported, candidate.CoAuthor)
+		}
+
+		if err := config.SaveConfig(); err != nil {
+			return err
+		}
+
+		if !isTableOutput() {
+			records := make([]coAuthorRecord, 0, len(imported))
+			for _, author := range imported {
+				records = append(records, newRecord(author, config.Config.CoAuthors, false))
+			}
+			return printRecords(records)
+		}
+
+		renderCoAuthorTable("Imported co-authors:", imported, func(author models.CoAuthor) string {
+			return author.Alias
+		})
+		fmt.Printf("Saved %d co-authors to %s\n", len(imported), config.GetConfigPath())
+		return nil
+	},
+}
+
+// loadRosterOrEmpty loads the roster with load, starting an empty one if there is no roster file yet
+func loadRosterOrEmpty(load func() error) error {
+	if err := load(); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		// Start a new roster
+		config.Config = models.Config{CoAuthorsMap: map[string]models.CoAuthor{}, Groups: map[string][]string{}}
+	}
+	return nil
+}
+
+// selectCandidates lets the user pick the candidates to import
+func selectCandidates(candidates []gitimport.Candidate) ([]gitimport.Candidate, error) {
+	indices, err := findMulti(
+		candidates,
+		func(i int) string {
+			return fmt.Sprintf("%s (%s) <%s>, %d commits", candidates[i].Name, candidates[i].Alias, candidates[i].Email, candidates[i].Commits)
+		},
+		fuzzyfinder.WithPromptString("Select co-authors to import using TAB:"),
+	)
+	if err != nil {
+		if err == fuzzyfinder.ErrAbort {
+			return nil, fmt.Errorf("selection canceled")
+		}
+		return nil, fmt.Errorf("fuzzy finder error: %w", err)
+	}
+
+	var selected []gitimport.Candidate
+	for _, idx := range indices {
+		selected = append(selected, candidates[idx])
+	}
+	return selected, nil
+}
+
+func init() {
+	importGitLogCmd.Flags().BoolVarP(&importAll, "all", "a", false, "import everyone without asking")
+	importCmd.AddCommand(importGitLogCmd)
+}
@@ -8,20 +8,25 @@ import (
 	"os"
 )
 
-func initConfig(cmd *cobra.Command, args []string) {
+func initConfig(cmd *cobra.Command, args []string) error {
 	// Check if the config file already exists
-	if _, err := os.Stat(config.ConfigPath); err == nil {
-		fmt.Printf("Config file already exists at %s. Use --config to specify a different path.\n", config.ConfigPath)
-		return
+	path := config.GetConfigPath()
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("Config file already exists at %s. Use --config to specify a different path.\n", path)
+		return nil
 	}
 
 	// Create sample config with the new format
 	sampleConfig := struct {
+		Schema       string `json:"$schema"`
+		Version      int    `json:"version"`
 		CoAuthorsMap map[string]struct {
 			Name  string `json:"name"`
 			Email string `json:"email"`
 		} `json:"coauthors"`
 	}{
+		Schema:  config.SchemaURL,
+		Version: config.CurrentVersion,
 		CoAuthorsMap: map[string]struct {
 			Name  string `json:"name"`
 			Email string `json:"email"`
@@ -39,19 +44,21 @@ func initConfig(cmd *cobra.Command, args []string) {
 
 	data, err := json.MarshalIndent(sampleConfig, "", "  ")
 	if err != nil {
-		fmt.Printf("Error creating sample config: %v\n", err)
-		return
+		return fmt.Errorf("error creating sample config: %w", err)
+	}
+	if data, err = config.EncodeFile(path, data); err != nil {
+		return err
 	}
 
-	if err := os.WriteFile(config.ConfigPath, data, 0644); err != nil {
-		fmt.Printf("Error writing config file: %v\n", err)
-		return
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
 	}
 
-	fmt.Printf("Created sample config file at %s\n", config.ConfigPath)
+	fmt.Printf("Created sample config file at %s\n", path)
 	fmt.Println("You can now use aliases to add co-authors, e.g.:")
 	fmt.Println("  pair add john")
 	fmt.Println("  pair add jane")
 	fmt.Println("Or use interactive selection with:")
 	fmt.Println("  pair select")
+	return nil
 }
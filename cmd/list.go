@@ -7,19 +7,44 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func listCoAuthors(cmd *cobra.Command, args []string) {
+func listCoAuthors(cmd *cobra.Command, args []string) error {
 	if err := config.LoadConfig(); err != nil {
-		fmt.Printf("Error: %v\n", err)
-		return
+		return err
 	}
 
 	if len(config.Config.CoAuthors) == 0 {
 		fmt.Println("No co-authors found in config. Use 'pair init' to create a sample config.")
-		return
+		return nil
+	}
+
+	if !isTableOutput() {
+		return printRecords(newRecords(config.Config.CoAuthors, isActiveCoAuthor()))
 	}
 
 	// Use the extracted helper function
 	renderCoAuthorTable("Available co-authors:", config.Config.CoAuthors, func(author models.CoAuthor) string {
 		return author.Alias
 	})
+
+	if len(config.Config.GroupNames) > 0 {
+		fmt.Println()
+		renderGroupTable("Groups:", config.Config.GroupNames, config.Config.Groups)
+	}
+	return nil
+}
+
+// isActiveCoAuthor returns a check whether a roster entry is currently active.
+// Outside of a repository or without a template nobody is active.
+func isActiveCoAuthor() func(author models.CoAuthor) bool {
+	var activeCoAuthors []models.CoAuthor
+	if scope, err := currentScope(); err == nil {
+		if _, active, err := loadActiveCoAuthors(scope); err == nil {
+			activeCoAuthors = active
+		}
+	}
+
+	matcher := newMatcher()
+	return func(author models.CoAuthor) bool {
+		return matcher.Contains(activeCoAuthors, author.Email)
+	}
 }
@@ -0,0 +1,313 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/mob"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var mobInterval time.Duration
+
+// errNoMob is returned by commands that need a running mob
+var errNoMob = errors.New("no mob is running, start one with 'pair mob start'")
+
+// mobCmd groups the commands managing a mob programming rotation
+var mobCmd = &cobra.Command{
+	Use:   "mob",
+	Short: "Manage a mob programming rotation",
+	Long: `Manage a mob programming rotation with a driver and navigators.
+The driver commits, so everyone else in the mob is credited as co-author.
+The rotation advances with "pair mob next" or automatically once the interval has elapsed.
+A mob in local or worktree scope belongs to the repository it was started in and is
+only rotated and stopped there.`,
+}
+
+var mobStartCmd = &cobra.Command{
+	Use:   "start [alias, group or index]...",
+	Short: "Start a mob rotation in the given order",
+	Args:  cobra.MinimumNArgs(2),
+	Example: "pair mob start alice bob carol --interval 10m\n" +
+		"pair mob start squad",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.LoadConfig(); err != nil {
+			return err
+		}
+
+		scope, err := currentScope()
+		if err != nil {
+			return err
+		}
+
+		running, err := mob.Load()
+		if err != nil {
+			return err
+		}
+		if running != nil {
+			return fmt.Errorf("a mob is already running, stop it first with 'pair mob stop'")
+		}
+
+		if mobInterval < 0 {
+			return fmt.Errorf("--interval must not be negative, got %s", mobInterval)
+		}
+
+		repository, err := journalTarget(scope)
+		if err != nil {
+			return err
+		}
+
+		state := &mob.State{RotatedAt: time.Now(), Scope: string(scope), Repository: repository}
+		if mobInterval > 0 {
+			state.Interval = mobInterval.String()
+		}
+
+		matcher := newMatcher()
+		for _, identifier := range args {
+			coAuthors, err := resolveCoAuthors(identifier)
+			if err != nil {
+				return fmt.Errorf("error with '%s': %w", identifier, err)
+			}
+
+			for _, coAuthor := range coAuthors {
+				duplicate := false
+				for _, member := range state.Members {
+					if matcher.Same(member.Email, coAuthor.Email) {
+						duplicate = true
+						break
+					}
+				}
+				if !duplicate {
+					state.Members = append(state.Members, mob.Member{Alias: coAuthor.Alias, Name: coAuthor.Name, Email: coAuthor.Email})
+				}
+			}
+		}
+
+		if len(state.Members) < 2 {
+			return fmt.Errorf("a mob needs at least two members")
+		}
+
+		if err := applyMob(state); err != nil {
+			return err
+		}
+
+		printMobStatus(state)
+		return nil
+	},
+}
+
+var mobNextCmd = &cobra.Command{
+	Use:   "next",
+	Short: "Hand the keyboard to the next driver",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := loadRunningMob()
+		if err != nil {
+			return err
+		}
+
+		state.Rotate(1, time.Now())
+		if err := applyMob(state); err != nil {
+			return err
+		}
+
+		printMobStatus(state)
+		return nil
+	},
+}
+
+var mobStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the current driver and rotation order",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := syncMob()
+		if err != nil {
+			return err
+		}
+		if state == nil {
+			return errNoMob
+		}
+
+		printMobStatus(state)
+		return nil
+	},
+}
+
+var mobStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the mob rotation and clear its co-authors",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := loadRunningMob()
+		if err != nil {
+			return err
+		}
+
+		scope, here, err := mobScope(state)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case here:
+			if err := clearTemplate(scope); err != nil {
+				return err
+			}
+		case repositoryExists(state.Repository):
+			return errMobElsewhere(state)
+		default:
+			// The repository is gone, so is its template
+			notef("The repository %s of the mob no longer exists\n", state.Repository)
+		}
+
+		if err := mob.Remove(); err != nil {
+			return err
+		}
+
+		fmt.Println("Mob stopped, all co-authors have been cleared")
+		return nil
+	},
+}
+
+// loadRunningMob returns the running mob session or an error if there is none
+func loadRunningMob() (*mob.State, error) {
+	state, err := mob.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, errNoMob
+	}
+	return state, nil
+}
+
+// mobScope returns the template scope of the mob and whether it can be written
+// here, which is only the case in the mob's repository for local and worktree scopes
+func mobScope(state *mob.State) (gittemplate.Scope, bool, error) {
+	scope, err := gittemplate.ParseScope(state.Scope)
+	if err != nil {
+		return "", false, err
+	}
+	if state.Repository == "" {
+		return scope, true, nil
+	}
+
+	repository, err := scope.GitDir()
+	if err != nil {
+		// Outside of any repository
+		return scope, false, nil
+	}
+	return scope, repository == state.Repository, nil
+}
+
+// errMobElsewhere is returned when a mob running in another repository is changed
+func errMobElsewhere(state *mob.State) error {
+	return fmt.Errorf("the mob is running in %s scope of %s, run this command there", state.Scope, state.Repository)
+}
+
+// repositoryExists reports whether the git directory still exists
+func repositoryExists(gitDir string) bool {
+	info, err := os.Stat(gitDir)
+	return err == nil && info.IsDir()
+}
+
+// applyMob persists the mob session and credits every navigator as co-author.
+// A mob of another repository is refused, its template cannot be written here.
+func applyMob(state *mob.State) error {
+	scope, here, err := mobScope(state)
+	if err != nil {
+		return err
+	}
+	if !here {
+		return errMobElsewhere(state)
+	}
+
+	// Credits other than Co-authored-by, e.g. added with --as reviewed-by, stay
+	var others []models.CoAuthor
+	templatePath, err := gittemplate.GetCurrentTemplate(scope)
+	if err != nil {
+		return err
+	}
+	active, err := gittemplate.ParseActiveCoAuthors(templatePath, config.TrailerKeys())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, author := range active {
+		if !author.IsCoAuthor() {
+			others = append(others, author)
+		}
+	}
+
+	if err := mob.Save(state); err != nil {
+		return err
+	}
+
+	return writeTemplate(scope, append(state.Navigators(), others...))
+}
+
+// syncMob applies the rotations whose timer has elapsed. It returns the running
+// mob session, or nil if there is none. Outside the mob's repository the
+// rotations are left for the next command run there.
+func syncMob() (*mob.State, error) {
+	state, err := mob.Load()
+	if err != nil || state == nil {
+		return state, err
+	}
+
+	_, here, err := mobScope(state)
+	if err != nil || !here {
+		return state, err
+	}
+
+	if steps := state.AdvanceTimer(time.Now()); steps > 0 {
+		if err := applyMob(state); err != nil {
+			return nil, err
+		}
+	}
+
+	return state, nil
+}
+
+// printMobStatus shows the rotation order and when the next driver takes over
+func printMobStatus(state *mob.State) {
+	t := newTable("Mob rotation:")
+	t.AppendHeader(table.Row{"#", "Alias", "Name", "Role"})
+
+	for i, member := range state.Members {
+		role := "navigator"
+		if i == 0 {
+			role = "driver"
+		}
+		t.AppendRow([]interface{}{i, member.Alias, member.Name, role})
+	}
+	t.Render()
+
+	if state.Repository != "" {
+		fmt.Printf("Credited in %s scope of %s\n", state.Scope, state.Repository)
+	}
+
+	driver := state.Driver()
+	fmt.Printf("%s is driving, everyone else is credited as co-author\n", driver.Name)
+
+	if next := state.NextRotation(); !next.IsZero() {
+		remaining := time.Until(next).Round(time.Second)
+		if remaining < 0 {
+			remaining = 0
+		}
+		fmt.Printf("Next rotation at %s (%s left)\n", next.Local().Format("15:04"), remaining)
+	} else {
+		fmt.Println("Rotate with 'pair mob next'")
+	}
+}
+
+func init() {
+	mobStartCmd.Flags().DurationVarP(&mobInterval, "interval", "i", 0, "rotate the driver automatically after this duration, e.g. 10m")
+	mobCmd.AddCommand(mobStartCmd, mobNextCmd, mobStatusCmd, mobStopCmd)
+}
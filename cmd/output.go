@@ -0,0 +1,261 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values of --output
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+	outputPlain = "plain"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML, outputCSV, outputPlain}
+
+// outputWriter receives machine-readable output, replaced in tests
+var outputWriter io.Writer = os.Stdout
+
+// coAuthorRecord is the machine-readable form of a co-author.
+// The index is the one accepted by "pair add" for roster entries and by
+// "pair remove" for active co-authors.
+type coAuthorRecord struct {
+	Index  int    `json:"index" yaml:"index"`
+	Alias  string `json:"alias" yaml:"alias"`
+	Name   string `json:"name" yaml:"name"`
+	Email  string `json:"email" yaml:"email"`
+	Active bool   `json:"active" yaml:"active"`
+
+	// Further addresses of roster entries, not part of csv and plain output
+	Emails []models.Email `json:"emails,omitempty" yaml:"emails,omitempty"`
+	// Trailer crediting active co-authors, not part of csv and plain output
+	Trailer string `json:"trailer,omitempty" yaml:"trailer,omitempty"`
+}
+
+// skippedRecord is a co-author a command did not change, with the reason why
+type skippedRecord struct {
+	coAuthorRecord `yaml:",inline"`
+	Reason         string `json:"reason" yaml:"reason"`
+}
+
+// changeReport describes what a mutating command changed
+type changeReport struct {
+	Added   []coAuthorRecord `json:"added" yaml:"added"`
+	Removed []coAuthorRecord `json:"removed" yaml:"removed"`
+	Skipped []skippedRecord  `json:"skipped" yaml:"skipped"`
+	Active  []coAuthorRecord `json:"active" yaml:"active"`
+}
+
+// activeReport describes the active co-authors shown by "pair show"
+type activeReport struct {
+	Scope     string           `json:"scope" yaml:"scope"`
+	Template  string           `json:"template" yaml:"template"`
+	Base      string           `json:"base_template,omitempty" yaml:"base_template,omitempty"`
+	Expires   string           `json:"expires,omitempty" yaml:"expires,omitempty"`
+	CoAuthors []coAuthorRecord `json:"coauthors" yaml:"coauthors"`
+}
+
+// outputFormat returns the format selected with --output or the settings file
+func outputFormat() string {
+	format := strings.ToLower(config.GetOutput())
+	if format == "" {
+		return outputTable
+	}
+	return format
+}
+
+// isTableOutput reports whether human-readable output was requested
+func isTableOutput() bool {
+	return outputFormat() == outputTable
+}
+
+// validateOutput rejects unknown output formats before any command runs
+func validateOutput(cmd *cobra.Command, args []string) error {
+	format := outputFormat()
+	for _, supported := range outputFormats {
+		if format == supported {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format '%s' (supported: %s)", format, strings.Join(outputFormats, ", "))
+}
+
+// notef prints a message for humans. With a machine-readable output format it goes
+// to stderr so that stdout only carries the requested format.
+func notef(format string, args ...interface{}) {
+	if isTableOutput() {
+		fmt.Printf(format, args...)
+		return
+	}
+	fmt.Fprintf(os.Stderr, format, args...)
+}
+
+// aliasFor returns the roster alias of the co-author, or an empty string
+func aliasFor(author models.CoAuthor) string {
+	if author.Alias != "" {
+		return author.Alias
+	}
+	if configAuthor, ok := newMatcher().Lookup(author.Email); ok {
+		return configAuthor.Alias
+	}
+	return ""
+}
+
+// newRecords converts co-authors into records, numbered by their position
+func newRecords(authors []models.CoAuthor, isActive func(author models.CoAuthor) bool) []coAuthorRecord {
+	records := make([]coAuthorRecord, 0, len(authors))
+	for i, author := range authors {
+		records = append(records, coAuthorRecord{
+			Index:   i,
+			Alias:   aliasFor(author),
+			Name:    author.Name,
+			Email:   author.Email,
+			Active:  isActive(author),
+			Emails:  author.Emails,
+			Trailer: author.TrailerKey,
+		})
+	}
+	return records
+}
+
+// newRecord converts a single co-author, looking up its index in list
+func newRecord(author models.CoAuthor, list []models.CoAuthor, active bool) coAuthorRecord {
+	index := -1
+	matcher := newMatcher()
+	for i, candidate := range list {
+		if matcher.Same(candidate.Email, author.Email) {
+			index = i
+			break
+		}
+	}
+	return coAuthorRecord{Index: index, Alias: aliasFor(author), Name: author.Name, Email: author.Email, Active: active, Emails: author.Emails, Trailer: author.TrailerKey}
+}
+
+// newChangeReport returns an empty report
+func newChangeReport() *changeReport {
+	return &changeReport{
+		Added:   []coAuthorRecord{},
+		Removed: []coAuthorRecord{},
+		Skipped: []skippedRecord{},
+		Active:  []coAuthorRecord{},
+	}
+}
+
+// added records a co-author that became active
+func (r *changeReport) added(author models.CoAuthor) {
+	r.Added = append(r.Added, newRecord(author, config.Config.CoAuthors, true))
+}
+
+// removed records a co-author that is no longer active
+func (r *changeReport) removed(author models.CoAuthor) {
+	r.Removed = append(r.Removed, newRecord(author, config.Config.CoAuthors, false))
+}
+
+// skipped records a co-author that was left as it was
+func (r *changeReport) skipped(author models.CoAuthor, active bool, reason string) {
+	r.Skipped = append(r.Skipped, skippedRecord{
+		coAuthorRecord: newRecord(author, config.Config.CoAuthors, active),
+		Reason:         reason,
+	})
+}
+
+// setActive records the resulting set of active co-authors
+func (r *changeReport) setActive(active []models.CoAuthor) {
+	r.Active = newRecords(active, func(models.CoAuthor) bool { return true })
+}
+
+// printRecords writes co-author records in the selected machine-readable format
+func printRecords(records []coAuthorRecord) error {
+	switch outputFormat() {
+	case outputJSON, outputYAML:
+		return encode(records)
+	case outputCSV:
+		w := csv.NewWriter(outputWriter)
+		if err := w.Write([]string{"index", "alias", "name", "email", "active"}); err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := w.Write(record.fields()); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	case outputPlain:
+		for _, record := range records {
+			fmt.Fprintln(outputWriter, strings.Join(record.fields(), "\t"))
+		}
+	}
+	return nil
+}
+
+// printReport writes what a mutating command changed in the selected machine-readable
+// format. Table output is printed while the command runs, so nothing is written here.
+func printReport(report *changeReport) error {
+	switch outputFormat() {
+	case outputJSON, outputYAML:
+		return encode(report)
+	case outputCSV, outputPlain:
+		rows := [][]string{}
+		for _, record := range report.Added {
+			rows = append(rows, append([]string{"added"}, append(record.fields(), "")...))
+		}
+		for _, record := range report.Removed {
+			rows = append(rows, append([]string{"removed"}, append(record.fields(), "")...))
+		}
+		for _, record := range report.Skipped {
+			rows = append(rows, append([]string{"skipped"}, append(record.fields(), record.Reason)...))
+		}
+
+		if outputFormat() == outputPlain {
+			for _, row := range rows {
+				fmt.Fprintln(outputWriter, strings.Join(row, "\t"))
+			}
+			return nil
+		}
+
+		w := csv.NewWriter(outputWriter)
+		if err := w.Write([]string{"change", "index", "alias", "name", "email", "active", "reason"}); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	}
+	return nil
+}
+
+// encode writes value as JSON or YAML
+func encode(value interface{}) error {
+	if outputFormat() == outputYAML {
+		encoder := yaml.NewEncoder(outputWriter)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	encoder := json.NewEncoder(outputWriter)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// fields returns the record as strings in the documented column order
+func (r coAuthorRecord) fields() []string {
+	return []string{strconv.Itoa(r.Index), r.Alias, r.Name, r.Email, strconv.FormatBool(r.Active)}
+}
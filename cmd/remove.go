@@ -3,8 +3,9 @@ package commands
 import (
 	"fmt"
 	"github.com/philippeckel/pair/internal/config"
-	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/models"
 	"github.com/spf13/cobra"
+	"strings"
 )
 
 func removeCoAuthor(cmd *cobra.Command, args []string) error {
@@ -12,14 +13,13 @@ func removeCoAuthor(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Get current template
-	templatePath, err := gittemplate.GetCurrentTemplate()
+	scope, err := currentScope()
 	if err != nil {
 		return err
 	}
 
 	// Get active co-authors
-	activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath)
+	_, activeCoAuthors, err := loadActiveCoAuthors(scope)
 	if err != nil {
 		return err
 	}
@@ -28,46 +28,75 @@ func removeCoAuthor(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no active co-authors to remove")
 	}
 
+	// With --as only the entries with that trailer are removed
+	trailerKey := ""
+	if cmd.Flags().Changed("as") {
+		if trailerKey, err = trailerKeyFlag(cmd); err != nil {
+			return err
+		}
+	}
+
 	identifier := args[0]
-	var indexToRemove = -1
+	var indicesToRemove []int
 
 	// Try to parse as index in the active co-authors list
 	var index int
 	if _, err := fmt.Sscanf(identifier, "%d", &index); err == nil {
 		if index >= 0 && index < len(activeCoAuthors) {
-			indexToRemove = index
+			indicesToRemove = append(indicesToRemove, index)
 		}
 	}
 
-	// If not found by index, try finding by alias in the config
-	if indexToRemove == -1 {
-		coAuthor, _, err := findCoAuthorByAliasOrIndex(identifier)
+	// If not found by index, try finding by group or alias in the config
+	if len(indicesToRemove) == 0 {
+		coAuthors, err := resolveCoAuthors(identifier)
 		if err != nil {
 			return err
 		}
 
-		// Find this co-author in the active list
+		// Find these co-authors in the active list
+		matcher := newMatcher()
 		for i, active := range activeCoAuthors {
-			if active.Email == coAuthor.Email {
-				indexToRemove = i
-				break
+			if trailerKey != "" && !strings.EqualFold(active.Key(), trailerKey) {
+				continue
+			}
+			for _, coAuthor := range coAuthors {
+				if matcher.Same(active.Email, coAuthor.Email) {
+					indicesToRemove = append(indicesToRemove, i)
+					break
+				}
 			}
 		}
 
-		if indexToRemove == -1 {
-			return fmt.Errorf("co-author '%s' is not currently active", coAuthor.Name)
+		if len(indicesToRemove) == 0 {
+			if _, isGroup := config.Config.Groups[identifier]; isGroup {
+				return fmt.Errorf("no member of group '%s' is currently active", identifier)
+			}
+			if trailerKey != "" {
+				return fmt.Errorf("co-author '%s' is not currently active as %s", coAuthors[0].Name, trailerKey)
+			}
+			return fmt.Errorf("co-author '%s' is not currently active", coAuthors[0].Name)
 		}
 	}
 
-	// Remove the co-author
-	removedAuthor := activeCoAuthors[indexToRemove]
-	activeCoAuthors = append(activeCoAuthors[:indexToRemove], activeCoAuthors[indexToRemove+1:]...)
+	// Remove the co-authors, starting from the end so indices stay valid
+	var removedAuthors []models.CoAuthor
+	for i := len(indicesToRemove) - 1; i >= 0; i-- {
+		indexToRemove := indicesToRemove[i]
+		removedAuthors = append([]models.CoAuthor{activeCoAuthors[indexToRemove]}, removedAuthors...)
+		activeCoAuthors = append(activeCoAuthors[:indexToRemove], activeCoAuthors[indexToRemove+1:]...)
+	}
 
 	// Update template
-	if err := gittemplate.UpdateTemplate(activeCoAuthors); err != nil {
+	if err := writeTemplate(scope, activeCoAuthors); err != nil {
 		return err
 	}
 
-	fmt.Printf("Removed co-author: %s <%s>\n", removedAuthor.Name, removedAuthor.Email)
-	return nil
+	report := newChangeReport()
+	for _, removedAuthor := range removedAuthors {
+		notef("Removed co-author: %s <%s>%s\n", removedAuthor.Name, removedAuthor.Email, trailerSuffix(removedAuthor))
+		report.removed(removedAuthor)
+	}
+	report.setActive(activeCoAuthors)
+	return printReport(report)
 }
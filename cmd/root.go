@@ -3,10 +3,11 @@ package commands
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/philippeckel/pair/internal/config"
-	"github.com/philippeckel/pair/internal/gittemplate"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // RootCmd represents the base command when called without any subcommands
@@ -22,7 +23,7 @@ var listCmd = &cobra.Command{
 	Long: `List all configured co-authors from your configuration file.
 		This command displays each co-author's name, email, and alias
 		in a formatted table.`,
-	Run: listCoAuthors,
+	RunE: listCoAuthors,
 	Example: "# List all co-authors\n" +
 		"pair list",
 	Aliases: []string{"ls"},
@@ -31,22 +32,26 @@ var listCmd = &cobra.Command{
 var showCmd = &cobra.Command{
 	Use:     "show",
 	Short:   "Show currently active co-authors",
-	Run:     showActiveCoAuthors,
+	RunE:    showActiveCoAuthors,
 	Aliases: []string{"s"},
 }
 
 var addCmd = &cobra.Command{
-	Use:     "add [alias or index]...",
-	Short:   "Add one or more co-authors to Git commits by alias or index",
+	Use:     "add [alias, group or index]...",
+	Short:   "Add one or more co-authors to Git commits by alias, group or index",
 	Args:    cobra.MinimumNArgs(1),
 	RunE:    addCoAuthor,
 	Aliases: []string{"a"},
-	Example: "pair add jane john",
+	Example: "pair add jane john\n" +
+		"pair add squad\n" +
+		"pair add jane --for 4h\n" +
+		"pair add jane --until 18:00\n" +
+		"pair add --as reviewed-by john",
 }
 
 var removeCmd = &cobra.Command{
-	Use:     "remove [alias or index]",
-	Short:   "Remove a co-author from Git commits by alias or index",
+	Use:     "remove [alias, group or index]",
+	Short:   "Remove a co-author or group from Git commits by alias, group or index",
 	Args:    cobra.ExactArgs(1),
 	RunE:    removeCoAuthor,
 	Aliases: []string{"rm"},
@@ -55,13 +60,13 @@ var removeCmd = &cobra.Command{
 var clearCmd = &cobra.Command{
 	Use:   "clear",
 	Short: "Clear all active co-authors",
-	Run:   clearCoAuthors,
+	RunE:  clearCoAuthors,
 }
 
 var initCmd = &cobra.Command{
 	Use:     "init",
 	Short:   "Initialize a new config file with sample co-authors",
-	Run:     initConfig,
+	RunE:    initConfig,
 	Aliases: []string{"i"},
 }
 
@@ -78,13 +83,13 @@ var selectCmd = &cobra.Command{
 			return fmt.Errorf("no co-authors found in config")
 		}
 
-		// Get active co-authors
-		templatePath, err := gittemplate.GetCurrentTemplate()
+		scope, err := currentScope()
 		if err != nil {
 			return err
 		}
 
-		activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath)
+		// Get active co-authors
+		_, activeCoAuthors, err := loadActiveCoAuthors(scope)
 		if err != nil {
 			return err
 		}
@@ -97,49 +102,86 @@ var selectCmd = &cobra.Command{
 
 		// Add the selected co-authors
 		added := false
+		report := newChangeReport()
+		matcher := newMatcher()
 		for _, coAuthor := range coAuthors {
 			// Check if already active
 			alreadyActive := false
 			for _, active := range activeCoAuthors {
-				if active.Email == coAuthor.Email {
+				if sameCredit(matcher, active, coAuthor) {
 					alreadyActive = true
-					fmt.Printf("Co-author already active: %s <%s>\n", coAuthor.Name, coAuthor.Email)
+					notef("Co-author already active: %s <%s>\n", coAuthor.Name, coAuthor.Email)
+					report.skipped(coAuthor, true, "already active")
 					break
 				}
 			}
 
 			if !alreadyActive {
 				activeCoAuthors = append(activeCoAuthors, coAuthor)
-				fmt.Printf("Added co-author: %s <%s>\n", coAuthor.Name, coAuthor.Email)
+				notef("Added co-author: %s <%s>\n", coAuthor.Name, coAuthor.Email)
+				report.added(coAuthor)
 				added = true
 			}
 		}
 
 		// Only update the template if at least one co-author was added
 		if added {
-			if err := gittemplate.UpdateTemplate(activeCoAuthors); err != nil {
+			if err := updateTemplate(cmd, scope, activeCoAuthors); err != nil {
 				return err
 			}
 		} else {
-			fmt.Println("No new co-authors were added")
+			notef("No new co-authors were added\n")
 		}
 
-		return nil
+		report.setActive(activeCoAuthors)
+		return printReport(report)
 	},
 	Aliases: []string{"s"},
 }
 
+func init() {
+	// Trailers besides Co-authored-by
+	for _, cmd := range []*cobra.Command{addCmd, removeCmd} {
+		cmd.Flags().String("as", "", "trailer crediting the co-authors, e.g. reviewed-by or signed-off-by (default co-authored-by)")
+		_ = cmd.RegisterFlagCompletionFunc("as", completeTrailerKeys)
+	}
+
+	// Time-boxed pairing sessions
+	for _, cmd := range []*cobra.Command{addCmd, selectCmd} {
+		cmd.Flags().Duration("for", 0, "end the pairing session after this duration, e.g. 4h")
+		cmd.Flags().String("until", "", "end the pairing session at this time, e.g. 18:00")
+		cmd.MarkFlagsMutuallyExclusive("for", "until")
+		cmd.PreRunE = validateSessionFlags
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	commandLine = strings.Join(append([]string{"pair"}, os.Args[1:]...), " ")
+
 	rootCmd.PersistentFlags().StringVarP(&config.ConfigPath, "config", "c",
-		config.GetConfigPath(), "config file path")
+		"", "roster file to use instead of merging the system, user and repository rosters")
+	rootCmd.PersistentFlags().String("scope", "global",
+		"commit template scope: global, local or worktree")
+	_ = viper.BindPFlag("scope", rootCmd.PersistentFlags().Lookup("scope"))
+	rootCmd.PersistentFlags().String("output", "table",
+		"output format: table, json, yaml, csv or plain")
+	_ = viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	rootCmd.PersistentPreRunE = validateOutput
+	// Errors are printed once by Execute, on stderr to keep machine-readable output intact
+	rootCmd.SilenceErrors = true
+
+	_ = rootCmd.RegisterFlagCompletionFunc("scope", cobra.FixedCompletions(
+		[]string{"global", "local", "worktree"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		outputFormats, cobra.ShellCompDirectiveNoFileComp))
 
 	// Add all subcommands
-	rootCmd.AddCommand(listCmd, showCmd, addCmd, removeCmd, clearCmd, initCmd, selectCmd, unselectCmd, docsCmd)
+	rootCmd.AddCommand(listCmd, showCmd, addCmd, removeCmd, clearCmd, initCmd, selectCmd, unselectCmd, docsCmd, hookCmd, mobCmd, amendCmd, statsCmd, suggestCmd, importCmd, configCmd, doctorCmd, undoCmd, redoCmd, historyCmd)
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 }
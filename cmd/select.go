@@ -7,6 +7,9 @@ import (
 	"strings"
 )
 
+// findMulti runs the fuzzy finder, replaced in tests
+var findMulti = fuzzyfinder.FindMulti
+
 // selectMultipleCoAuthors allows selecting multiple co-authors at once
 func selectMultipleCoAuthors(coAuthors []models.CoAuthor, activeCoAuthors []models.CoAuthor) ([]models.CoAuthor, error) {
 	userName, userEmail, err := getGitUserInfo()
@@ -16,19 +19,13 @@ func selectMultipleCoAuthors(coAuthors []models.CoAuthor, activeCoAuthors []mode
 
 	// Filter out already active co-authors
 	var availableCoAuthors []models.CoAuthor
+	matcher := newMatcher()
 	for _, author := range coAuthors {
 		// Skip yourself - check both name and email
-		if strings.EqualFold(author.Email, userEmail) || strings.EqualFold(author.Name, userName) {
+		if matcher.Same(author.Email, userEmail) || strings.EqualFold(author.Name, userName) {
 			continue
 		}
-		isActive := false
-		for _, active := range activeCoAuthors {
-			if author.Email == active.Email {
-				isActive = true
-				break
-			}
-		}
-		if !isActive {
+		if !matcher.Contains(activeCoAuthors, author.Email) {
 			availableCoAuthors = append(availableCoAuthors, author)
 		}
 	}
@@ -39,7 +36,7 @@ func selectMultipleCoAuthors(coAuthors []models.CoAuthor, activeCoAuthors []mode
 	}
 
 	// Run the fuzzy finder and get selected indices
-	indices, err := fuzzyfinder.FindMulti(
+	indices, err := findMulti(
 		availableCoAuthors,
 		func(i int) string {
 			return fmt.Sprintf("%s (%s) <%s>", availableCoAuthors[i].Name, availableCoAuthors[i].Alias, availableCoAuthors[i].Email)
@@ -71,7 +68,7 @@ func selectMultipleCoAuthorsToRemove(activeCoAuthors []models.CoAuthor) ([]model
 	}
 
 	// Run the fuzzy finder and get selected indices
-	indices, err := fuzzyfinder.FindMulti(
+	indices, err := findMulti(
 		activeCoAuthors,
 		func(i int) string {
 			return fmt.Sprintf("%s <%s>", activeCoAuthors[i].Name, activeCoAuthors[i].Email)
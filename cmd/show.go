@@ -6,29 +6,42 @@ import (
 	"github.com/philippeckel/pair/internal/gittemplate"
 	"github.com/philippeckel/pair/internal/models"
 	"github.com/spf13/cobra"
+	"time"
 )
 
-func showActiveCoAuthors(cmd *cobra.Command, args []string) {
-	templatePath, err := gittemplate.GetCurrentTemplate()
+func showActiveCoAuthors(cmd *cobra.Command, args []string) error {
+	scope, err := currentScope()
 	if err != nil {
-		fmt.Printf("Error getting current git template: %v\n", err)
-		return
+		return err
+	}
+
+	templatePath, activeCoAuthors, err := loadActiveCoAuthors(scope)
+	if err != nil {
+		return fmt.Errorf("error reading active co-authors: %w", err)
+	}
+
+	if !isTableOutput() {
+		return printActiveReport(scope, templatePath, activeCoAuthors)
 	}
 
 	if templatePath == "" {
-		fmt.Println("No commit template is currently set. No active co-authors.")
-		return
+		fmt.Printf("No commit template is currently set in %s scope. No active co-authors.\n", scope)
+		return nil
 	}
 
-	activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath)
-	if err != nil {
-		fmt.Printf("Error parsing active co-authors: %v\n", err)
-		return
+	fmt.Printf("Scope: %s (%s)\n", scope, templatePath)
+	if basePath, err := gittemplate.GetBaseTemplate(scope); err == nil && basePath != "" {
+		fmt.Printf("Base template: %s\n", basePath)
+	}
+
+	if expires, err := gittemplate.ReadExpiry(templatePath); err == nil && !expires.IsZero() {
+		remaining := time.Until(expires).Round(time.Minute)
+		fmt.Printf("Session ends at %s (%s left)\n", expires.Local().Format(sessionTimeLayout), remaining)
 	}
 
 	if len(activeCoAuthors) == 0 {
 		fmt.Println("No active co-authors found.")
-		return
+		return nil
 	}
 
 	// Load config to get aliases
@@ -38,13 +51,29 @@ func showActiveCoAuthors(cmd *cobra.Command, args []string) {
 	}
 
 	// Use the extracted helper function
-	renderCoAuthorTable("Active co-authors:", activeCoAuthors, func(author models.CoAuthor) string {
-		// Find alias from config if available
-		for configAlias, configAuthor := range config.Config.CoAuthorsMap {
-			if configAuthor.Email == author.Email {
-				return configAlias
-			}
-		}
-		return ""
-	})
+	renderCoAuthorTable("Active co-authors:", activeCoAuthors, aliasFor)
+	return nil
+}
+
+// printActiveReport writes the active co-authors in the selected machine-readable format
+func printActiveReport(scope gittemplate.Scope, templatePath string, activeCoAuthors []models.CoAuthor) error {
+	// Aliases are optional, so a missing config is not an error
+	_ = config.LoadConfig()
+
+	report := activeReport{
+		Scope:     string(scope),
+		Template:  templatePath,
+		CoAuthors: newRecords(activeCoAuthors, func(models.CoAuthor) bool { return true }),
+	}
+	if basePath, err := gittemplate.GetBaseTemplate(scope); err == nil {
+		report.Base = basePath
+	}
+	if expires, err := gittemplate.ReadExpiry(templatePath); err == nil && !expires.IsZero() {
+		report.Expires = expires.Format(time.RFC3339)
+	}
+
+	if outputFormat() == outputJSON || outputFormat() == outputYAML {
+		return encode(report)
+	}
+	return printRecords(report.CoAuthors)
 }
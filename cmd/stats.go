@@ -0,0 +1,318 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/philippeckel/pair/internal/stats"
+	"github.com/spf13/cobra"
+)
+
+// statsDateLayout is used for the dates in the statistics
+const statsDateLayout = "2006-01-02"
+
+var statsWindow stats.Window
+
+// personRecord identifies a person in the statistics, the alias is empty
+// for people that are not in the roster
+type personRecord struct {
+	Alias string `json:"alias" yaml:"alias"`
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+}
+
+// statsPersonRecord is the machine-readable form of stats.Person
+type statsPersonRecord struct {
+	personRecord  `yaml:",inline"`
+	Commits       int    `json:"commits" yaml:"commits"`
+	PairedCommits int    `json:"paired_commits" yaml:"paired_commits"`
+	LastPaired    string `json:"last_paired,omitempty" yaml:"last_paired,omitempty"`
+}
+
+// statsPairRecord is the machine-readable form of stats.Pair
+type statsPairRecord struct {
+	People     [2]personRecord `json:"people" yaml:"people"`
+	Commits    int             `json:"commits" yaml:"commits"`
+	LastPaired string          `json:"last_paired" yaml:"last_paired"`
+}
+
+// statsReport is the machine-readable form of stats.Report
+type statsReport struct {
+	Since         string              `json:"since,omitempty" yaml:"since,omitempty"`
+	Until         string              `json:"until,omitempty" yaml:"until,omitempty"`
+	Commits       int                 `json:"commits" yaml:"commits"`
+	PairedCommits int                 `json:"paired_commits" yaml:"paired_commits"`
+	People        []statsPersonRecord `json:"people" yaml:"people"`
+	Pairs         []statsPairRecord   `json:"pairs" yaml:"pairs"`
+}
+
+// statsCmd reports who pairs with whom, mined from the commit history
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show pairing statistics of the current repository",
+	Long: `Show how often people paired, based on the authors and Co-authored-by trailers
+of the commits reachable from HEAD. Emails are mapped back to roster aliases.
+Everyone on a commit counts as having paired with everyone else on it.`,
+	Example: "pair stats\n" +
+		"pair stats --since \"4 weeks ago\"\n" +
+		"pair stats --since 2024-01-01 --until 2024-03-31 --output json",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := outputFormat()
+		if format == outputCSV || format == outputPlain {
+			return fmt.Errorf("stats supports table, json and yaml output")
+		}
+
+		// The roster only provides aliases, statistics work without one
+		_ = config.LoadConfig()
+
+		commits, err := readHistory()
+		if err != nil {
+			return err
+		}
+		report := stats.Build(commits)
+
+		if !isTableOutput() {
+			return encode(newStatsReport(report))
+		}
+
+		printStats(report)
+		return nil
+	},
+}
+
+// readHistory reads the commits in the window. Every address is replaced by the
+// primary email of its roster entry or its .mailmap canonical form, so that each
+// person is counted once.
+func readHistory() ([]stats.Commit, error) {
+	commits, err := stats.Log(statsWindow, config.TrailerKeys())
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := newMatcher()
+	var emails []string
+	for _, commit := range commits {
+		for _, person := range commit.Participants() {
+			emails = append(emails, person.Email)
+		}
+	}
+	matcher.Preload(emails)
+
+	canonicalize := func(person models.CoAuthor) models.CoAuthor {
+		if author, ok := matcher.Lookup(person.Email); ok {
+			return models.CoAuthor{Name: author.Name, Email: author.Email}
+		}
+		return models.CoAuthor{Name: person.Name, Email: matcher.Key(person.Email)}
+	}
+
+	for i, commit := range commits {
+		commits[i].Author = canonicalize(commit.Author)
+		for j, coAuthor := range commit.CoAuthors {
+			commits[i].CoAuthors[j] = canonicalize(coAuthor)
+		}
+	}
+	return commits, nil
+}
+
+// newPersonRecord identifies a person, looking up the alias in the roster
+func newPersonRecord(name, email string) personRecord {
+	return personRecord{Alias: aliasFor(models.CoAuthor{Name: name, Email: email}), Name: name, Email: email}
+}
+
+// displayName returns the alias of a roster entry and the name of anybody else
+func (p personRecord) displayName() string {
+	if p.Alias != "" {
+		return p.Alias
+	}
+	return p.Name
+}
+
+// formatDate formats a date of the statistics, a zero date is left empty
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(statsDateLayout)
+}
+
+// newStatsReport converts the statistics into their machine-readable form
+func newStatsReport(report *stats.Report) statsReport {
+	result := statsReport{
+		Since:         statsWindow.Since,
+		Until:         statsWindow.Until,
+		Commits:       report.Commits,
+		PairedCommits: report.PairedCommits,
+		People:        []statsPersonRecord{},
+		Pairs:         []statsPairRecord{},
+	}
+
+	for _, person := range report.People {
+		result.People = append(result.People, statsPersonRecord{
+			personRecord:  newPersonRecord(person.Name, person.Email),
+			Commits:       person.Commits,
+			PairedCommits: person.PairedCommits,
+			LastPaired:    formatDate(person.LastPaired),
+		})
+	}
+
+	for _, pair := range report.Pairs {
+		result.Pairs = append(result.Pairs, statsPairRecord{
+			People:     [2]personRecord{newPersonRecord(pair.A.Name, pair.A.Email), newPersonRecord(pair.B.Name, pair.B.Email)},
+			Commits:    pair.Commits,
+			LastPaired: formatDate(pair.LastPaired),
+		})
+	}
+
+	return result
+}
+
+// printStats renders the statistics as tables
+func printStats(report *stats.Report) {
+	records := newStatsReport(report)
+
+	fmt.Printf("%d commits, %d of them paired\n\n", records.Commits, records.PairedCommits)
+	if records.Commits == 0 {
+		return
+	}
+
+	t := newTable("People:")
+	t.AppendHeader(table.Row{"Alias", "Name", "Commits", "Paired", "Last paired"})
+	for _, person := range records.People {
+		t.AppendRow([]interface{}{person.Alias, person.Name, person.Commits, person.PairedCommits, person.LastPaired})
+	}
+	t.Render()
+
+	if len(records.Pairs) == 0 {
+		return
+	}
+
+	fmt.Println()
+	t = newTable("Pairs:")
+	t.AppendHeader(table.Row{"Pair", "Commits", "Last paired"})
+	for _, pair := range records.Pairs {
+		t.AppendRow([]interface{}{pair.People[0].displayName() + " + " + pair.People[1].displayName(), pair.Commits, pair.LastPaired})
+	}
+	t.Render()
+}
+
+// statsMatrixCmd shows how often every two roster members paired
+var statsMatrixCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "Show how often every two roster members paired",
+	Long: `Show a matrix with a row and a column for every roster alias, each cell
+holding the number of commits the two co-authored. Use "--output csv" to open it
+in a spreadsheet.`,
+	Example: "pair stats matrix --since \"3 months ago\"\n" +
+		"pair stats matrix --output csv > pairing.csv",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.LoadConfig(); err != nil {
+			return err
+		}
+
+		commits, err := readHistory()
+		if err != nil {
+			return err
+		}
+		report := stats.Build(commits)
+
+		matrix := matrixReport{Since: statsWindow.Since, Until: statsWindow.Until, Aliases: []string{}, Commits: [][]int{}}
+		for _, row := range config.Config.CoAuthors {
+			matrix.Aliases = append(matrix.Aliases, row.Alias)
+
+			cells := make([]int, 0, len(config.Config.CoAuthors))
+			for _, column := range config.Config.CoAuthors {
+				pair, _ := report.Pair(row.Email, column.Email)
+				cells = append(cells, pair.Commits)
+			}
+			matrix.Commits = append(matrix.Commits, cells)
+		}
+
+		return printMatrix(matrix)
+	},
+}
+
+// matrixReport is the pairing matrix, Commits[i][j] is the number of commits
+// Aliases[i] and Aliases[j] made together
+type matrixReport struct {
+	Since   string   `json:"since,omitempty" yaml:"since,omitempty"`
+	Until   string   `json:"until,omitempty" yaml:"until,omitempty"`
+	Aliases []string `json:"aliases" yaml:"aliases"`
+	Commits [][]int  `json:"commits" yaml:"commits,flow"`
+}
+
+// printMatrix writes the matrix in the selected output format. The diagonal
+// is left empty in tables and CSV.
+func printMatrix(matrix matrixReport) error {
+	rows := make([][]string, len(matrix.Aliases))
+	for i, alias := range matrix.Aliases {
+		rows[i] = []string{alias}
+		for j, commits := range matrix.Commits[i] {
+			cell := strconv.Itoa(commits)
+			if i == j {
+				cell = ""
+			}
+			rows[i] = append(rows[i], cell)
+		}
+	}
+
+	switch outputFormat() {
+	case outputJSON, outputYAML:
+		return encode(matrix)
+	case outputCSV:
+		w := csv.NewWriter(outputWriter)
+		if err := w.Write(append([]string{""}, matrix.Aliases...)); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	case outputPlain:
+		for _, row := range rows {
+			fmt.Fprintln(outputWriter, strings.Join(row, "\t"))
+		}
+		return nil
+	}
+
+	t := newTable("Pairing matrix (co-authored commits):")
+	header := table.Row{""}
+	for _, alias := range matrix.Aliases {
+		header = append(header, alias)
+	}
+	t.AppendHeader(header)
+
+	for i, alias := range matrix.Aliases {
+		cells := []interface{}{alias}
+		for j, commits := range matrix.Commits[i] {
+			if i == j {
+				cells = append(cells, "-")
+				continue
+			}
+			cells = append(cells, commits)
+		}
+		t.AppendRow(cells)
+	}
+	t.Render()
+	return nil
+}
+
+// addWindowFlags adds the flags limiting the commits read from the log
+func addWindowFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.StringVar(&statsWindow.Since, "since", "", "only count commits after this date, e.g. 2024-01-01 or \"2 weeks ago\"")
+	flags.StringVar(&statsWindow.Until, "until", "", "only count commits before this date")
+}
+
+func init() {
+	addWindowFlags(statsCmd)
+	addWindowFlags(statsMatrixCmd)
+	statsCmd.AddCommand(statsMatrixCmd)
+}
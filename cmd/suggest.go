@@ -0,0 +1,151 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/stats"
+	"github.com/spf13/cobra"
+)
+
+var suggestLimit int
+
+// suggestionRecord is a roster member to pair with next
+type suggestionRecord struct {
+	personRecord `yaml:",inline"`
+	Commits      int    `json:"commits" yaml:"commits"`
+	LastPaired   string `json:"last_paired" yaml:"last_paired"`
+}
+
+// suggestCmd recommends roster members the current git user has not paired with lately
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest who to pair with next",
+	Long: `Suggest the roster members you paired with least recently, based on the commit
+history of the current repository. People you never paired with come first, then the
+ones you paired with longest ago. You are identified by git's user.email.`,
+	Example: "pair suggest\n" +
+		"pair suggest --limit 0 --since \"6 months ago\"",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.LoadConfig(); err != nil {
+			return err
+		}
+
+		_, email, err := getGitUserInfo()
+		if err != nil {
+			return err
+		}
+
+		commits, err := readHistory()
+		if err != nil {
+			return err
+		}
+		report := stats.Build(commits)
+
+		suggestions := suggestPartners(report, email)
+		if suggestLimit > 0 && len(suggestions) > suggestLimit {
+			suggestions = suggestions[:suggestLimit]
+		}
+
+		return printSuggestions(suggestions)
+	},
+}
+
+// suggestPartners orders the roster by when they last paired with the given email,
+// never first, leaving out the person with that email
+func suggestPartners(report *stats.Report, email string) []suggestionRecord {
+	type candidate struct {
+		record suggestionRecord
+		pair   stats.Pair
+	}
+
+	var candidates []candidate
+	matcher := newMatcher()
+	for _, author := range config.Config.CoAuthors {
+		if matcher.Same(author.Email, email) {
+			continue
+		}
+
+		pair, _ := report.Pair(email, author.Email)
+		candidates = append(candidates, candidate{
+			record: suggestionRecord{
+				personRecord: personRecord{Alias: author.Alias, Name: author.Name, Email: author.Email},
+				Commits:      pair.Commits,
+				LastPaired:   formatDate(pair.LastPaired),
+			},
+			pair: pair,
+		})
+	}
+
+	// Stable to keep the roster order among people that never paired
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].pair, candidates[j].pair
+		if !a.LastPaired.Equal(b.LastPaired) {
+			return a.LastPaired.Before(b.LastPaired)
+		}
+		return a.Commits < b.Commits
+	})
+
+	suggestions := make([]suggestionRecord, 0, len(candidates))
+	for _, c := range candidates {
+		suggestions = append(suggestions, c.record)
+	}
+	return suggestions
+}
+
+// printSuggestions writes the suggestions in the selected output format
+func printSuggestions(suggestions []suggestionRecord) error {
+	rows := make([][]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		rows = append(rows, []string{s.Alias, s.Name, s.Email, strconv.Itoa(s.Commits), s.LastPaired})
+	}
+
+	switch outputFormat() {
+	case outputJSON, outputYAML:
+		return encode(suggestions)
+	case outputCSV:
+		w := csv.NewWriter(outputWriter)
+		if err := w.Write([]string{"alias", "name", "email", "commits", "last_paired"}); err != nil {
+			return err
+		}
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return w.Error()
+	case outputPlain:
+		for _, row := range rows {
+			fmt.Fprintln(outputWriter, strings.Join(row, "\t"))
+		}
+		return nil
+	}
+
+	if len(suggestions) == 0 {
+		fmt.Println("Nobody else is in the roster")
+		return nil
+	}
+
+	t := newTable("Pair with:")
+	t.AppendHeader(table.Row{"Alias", "Name", "Commits together", "Last paired"})
+	for _, s := range suggestions {
+		lastPaired := s.LastPaired
+		if lastPaired == "" {
+			lastPaired = "never"
+		}
+		t.AppendRow([]interface{}{s.Alias, s.Name, s.Commits, lastPaired})
+	}
+	t.Render()
+
+	fmt.Printf("Start with 'pair add %s'\n", suggestions[0].Alias)
+	return nil
+}
+
+func init() {
+	suggestCmd.Flags().IntVarP(&suggestLimit, "limit", "n", 3, "number of suggestions, 0 for the whole roster")
+	addWindowFlags(suggestCmd)
+}
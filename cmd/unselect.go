@@ -3,7 +3,7 @@ package commands
 import (
 	"fmt"
 
-	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/config"
 	"github.com/philippeckel/pair/internal/models"
 	"github.com/spf13/cobra"
 )
@@ -14,14 +14,13 @@ var unselectCmd = &cobra.Command{
 	Short: "Interactively remove co-authors using fuzzy finder",
 	Long:  `Use fuzzy finder to interactively remove active co-authors`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Get current template
-		templatePath, err := gittemplate.GetCurrentTemplate()
+		scope, err := currentScope()
 		if err != nil {
 			return err
 		}
 
 		// Get active co-authors
-		activeCoAuthors, err := gittemplate.ParseActiveCoAuthors(templatePath)
+		_, activeCoAuthors, err := loadActiveCoAuthors(scope)
 		if err != nil {
 			return err
 		}
@@ -40,14 +39,22 @@ var unselectCmd = &cobra.Command{
 			return fmt.Errorf("no co-authors selected for removal")
 		}
 
+		if !isTableOutput() {
+			// Aliases are only needed for the report
+			_ = config.LoadConfig()
+		}
+
 		// Create new list without the removed co-authors
+		report := newChangeReport()
 		var newActiveCoAuthors []models.CoAuthor
+		matcher := newMatcher()
 		for _, author := range activeCoAuthors {
 			shouldRemove := false
 			for _, remove := range toRemove {
-				if author.Email == remove.Email {
+				if matcher.Same(author.Email, remove.Email) {
 					shouldRemove = true
-					fmt.Printf("Removing co-author: %s <%s>\n", remove.Name, remove.Email)
+					notef("Removing co-author: %s <%s>\n", remove.Name, remove.Email)
+					report.removed(remove)
 					break
 				}
 			}
@@ -57,12 +64,13 @@ var unselectCmd = &cobra.Command{
 		}
 
 		// Update git template
-		if err := gittemplate.UpdateTemplate(newActiveCoAuthors); err != nil {
+		if err := writeTemplate(scope, newActiveCoAuthors); err != nil {
 			return err
 		}
 
-		fmt.Printf("Removed %d co-authors from your commit template\n", len(toRemove))
-		return nil
+		notef("Removed %d co-authors from your commit template\n", len(toRemove))
+		report.setActive(newActiveCoAuthors)
+		return printReport(report)
 	},
 	Aliases: []string{"us"},
 }
@@ -22,7 +22,9 @@ export default defineConfig({
         text: "Configuration",
         items: [
           { text: "Configuration file", link: "/configuration-file" },
+          { text: "Roster file", link: "/roster-file" },
           { text: "Environment variables", link: "/environment-variables" },
+          { text: "Output formats", link: "/output-formats" },
         ],
       },
       {
@@ -0,0 +1,245 @@
+package amend
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+)
+
+// Rewrite describes a commit whose message was rewritten
+type Rewrite struct {
+	Old     string // Hash of the original commit
+	New     string // Hash of the rewritten commit
+	Subject string // First line of the commit message
+}
+
+// commit is a commit to rewrite together with its parents
+type commit struct {
+	hash    string
+	parents []string
+}
+
+// AddTrailers adds the trailer of each co-author, Co-authored-by by default, to message,
+// skipping trailers that are already present
+func AddTrailers(message string, coAuthors []models.CoAuthor) (string, error) {
+	args := []string{"interpret-trailers", "--if-exists", "addIfDifferent"}
+	for _, author := range coAuthors {
+		args = append(args, "--trailer", author.Trailer())
+	}
+
+	output, err := git.RunInput(message, args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to add trailers: %w", err)
+	}
+	return output, nil
+}
+
+// Commits rewrites the messages of the commits in revRange to credit the co-authors
+// and moves the current branch to the rewritten history. The range must end at HEAD;
+// a single revision such as "HEAD~3" means "HEAD~3..HEAD". Unless force is set,
+// commits that are already on a remote branch are refused.
+func Commits(revRange string, coAuthors []models.CoAuthor, force bool) ([]Rewrite, error) {
+	if !strings.Contains(revRange, "..") {
+		revRange += "..HEAD"
+	}
+
+	commits, err := listCommits(revRange)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("no commits in range %s", revRange)
+	}
+
+	return rewrite(commits, coAuthors, force)
+}
+
+// Head rewrites the message of the HEAD commit to credit the co-authors
+func Head(coAuthors []models.CoAuthor, force bool) ([]Rewrite, error) {
+	commits, err := listCommits("--max-count=1", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	return rewrite(commits, coAuthors, force)
+}
+
+// rewrite adds the trailers to the commits, which are ordered parents before
+// children and must end at HEAD, and moves the current branch to the result
+func rewrite(commits []commit, coAuthors []models.CoAuthor, force bool) ([]Rewrite, error) {
+	head, err := revParse("HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if commits[len(commits)-1].hash != head {
+		return nil, fmt.Errorf("the range must end at HEAD")
+	}
+
+	if !force {
+		if err := refusePushed(commits); err != nil {
+			return nil, err
+		}
+	}
+
+	rewritten := make(map[string]string)
+	var rewrites []Rewrite
+
+	for _, c := range commits {
+		newHash, subject, err := rewriteCommit(c, rewritten, coAuthors)
+		if err != nil {
+			return nil, err
+		}
+		if newHash != c.hash {
+			rewritten[c.hash] = newHash
+			rewrites = append(rewrites, Rewrite{Old: c.hash, New: newHash, Subject: subject})
+		}
+	}
+
+	newHead, ok := rewritten[head]
+	if !ok {
+		return rewrites, nil
+	}
+
+	if _, err := git.Run("update-ref", "-m", "pair amend: add co-authors", "HEAD", newHead, head); err != nil {
+		return nil, fmt.Errorf("failed to update HEAD: %w", err)
+	}
+
+	return rewrites, nil
+}
+
+// revParse resolves a revision to a commit hash
+func revParse(revision string) (string, error) {
+	output, err := git.Run("rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %s: %w", revision, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// listCommits returns the commits selected by the rev-list arguments, parents before children
+func listCommits(revisions ...string) ([]commit, error) {
+	args := append([]string{"rev-list", "--reverse", "--topo-order", "--parents"}, revisions...)
+	output, err := git.Run(args...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid range %s: %w", strings.Join(revisions, " "), err)
+	}
+
+	var commits []commit
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		commits = append(commits, commit{hash: fields[0], parents: fields[1:]})
+	}
+	return commits, nil
+}
+
+// refusePushed returns an error if any of the commits is contained in a remote
+// branch. A range can hold pushed commits after unpushed ones, e.g. a side
+// branch that was pushed and then merged, so every commit is checked.
+func refusePushed(commits []commit) error {
+	args := []string{"rev-list"}
+	for _, c := range commits {
+		args = append(args, c.hash)
+	}
+	output, err := git.Run(append(args, "--not", "--remotes")...)
+	if err != nil {
+		return fmt.Errorf("could not check remote branches: %w", err)
+	}
+
+	unpushed := make(map[string]bool)
+	for _, hash := range strings.Fields(output) {
+		unpushed[hash] = true
+	}
+
+	for _, c := range commits {
+		if unpushed[c.hash] {
+			continue
+		}
+
+		output, err := git.Run("branch", "--remotes", "--contains", c.hash)
+		if err != nil {
+			return fmt.Errorf("could not check remote branches: %w", err)
+		}
+		return fmt.Errorf("commit %s is already on %s, rewriting it would change published history (use --force to rewrite anyway)",
+			shortHash(c.hash), strings.Join(strings.Fields(output), ", "))
+	}
+	return nil
+}
+
+// rewriteCommit creates a copy of the commit with the trailers added and parents
+// replaced by their rewritten versions. The original commit is returned unchanged
+// if neither its message nor its parents change. The author of the commit is
+// never credited as their own co-author, other trailers such as Signed-off-by are kept.
+func rewriteCommit(c commit, rewritten map[string]string, coAuthors []models.CoAuthor) (string, string, error) {
+	message, err := git.Run("log", "-1", "--format=%B", c.hash)
+	if err != nil {
+		return "", "", fmt.Errorf("could not read commit %s: %w", shortHash(c.hash), err)
+	}
+	message = strings.TrimRight(message, "\n") + "\n"
+	subject, _, _ := strings.Cut(message, "\n")
+
+	// Keep the original authorship, the committer becomes the current user as with rebase
+	author, err := git.Run("log", "-1", "--format=%an%x00%ae%x00%ad", "--date=raw", c.hash)
+	if err != nil {
+		return "", "", fmt.Errorf("could not read author of %s: %w", shortHash(c.hash), err)
+	}
+	authorFields := strings.SplitN(strings.TrimRight(author, "\n"), "\x00", 3)
+	if len(authorFields) != 3 {
+		return "", "", fmt.Errorf("could not parse author of %s", shortHash(c.hash))
+	}
+
+	var credited []models.CoAuthor
+	for _, coAuthor := range coAuthors {
+		if !coAuthor.IsCoAuthor() || !strings.EqualFold(coAuthor.Email, authorFields[1]) {
+			credited = append(credited, coAuthor)
+		}
+	}
+
+	newMessage := message
+	if len(credited) > 0 {
+		if newMessage, err = AddTrailers(message, credited); err != nil {
+			return "", "", err
+		}
+	}
+
+	parentsChanged := false
+	args := []string{"commit-tree", c.hash + "^{tree}"}
+	for _, parent := range c.parents {
+		if newParent, ok := rewritten[parent]; ok {
+			parent = newParent
+			parentsChanged = true
+		}
+		args = append(args, "-p", parent)
+	}
+
+	if !parentsChanged && newMessage == message {
+		return c.hash, subject, nil
+	}
+
+	output, err := git.Default.Run(git.Command{
+		Args:  append(args, "-F", "-"),
+		Stdin: newMessage,
+		Env: []string{
+			"GIT_AUTHOR_NAME=" + authorFields[0],
+			"GIT_AUTHOR_EMAIL=" + authorFields[1],
+			"GIT_AUTHOR_DATE=" + authorFields[2],
+		},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("could not rewrite commit %s: %w", shortHash(c.hash), err)
+	}
+
+	return strings.TrimSpace(output), subject, nil
+}
+
+// shortHash abbreviates a commit hash for messages
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,149 @@
+package amend
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRepo creates a repository with the given commit subjects and changes into it
+func setupRepo(t *testing.T, subjects ...string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "Alex Author"},
+		{"config", "user.email", "alex@example.com"},
+	} {
+		_, err := git.Run(args...)
+		require.NoError(t, err)
+	}
+
+	for _, subject := range subjects {
+		_, err := git.Run("commit", "--quiet", "--allow-empty", "-m", subject)
+		require.NoError(t, err)
+	}
+}
+
+// messages returns the commit messages from HEAD backwards
+func messages(t *testing.T, count string) []string {
+	t.Helper()
+	output, err := git.Run("log", "--max-count="+count, "--format=%B%x00")
+	require.NoError(t, err)
+
+	var result []string
+	for _, message := range strings.Split(output, "\x00") {
+		if message = strings.TrimSpace(message); message != "" {
+			result = append(result, message)
+		}
+	}
+	return result
+}
+
+func TestHead(t *testing.T) {
+	setupRepo(t, "first", "second")
+	jane := models.CoAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+	alex := models.CoAuthor{Name: "Alex Author", Email: "alex@example.com"}
+
+	rewrites, err := Head([]models.CoAuthor{jane, alex}, false)
+	require.NoError(t, err)
+	require.Len(t, rewrites, 1)
+	assert.Equal(t, "second", rewrites[0].Subject)
+
+	// The author is not credited as their own co-author and older commits are kept
+	assert.Equal(t, []string{
+		"second\n\nCo-authored-by: Jane Doe <jane@example.com>",
+		"first",
+	}, messages(t, "2"))
+
+	// Amending again does not duplicate the trailer
+	rewrites, err = Head([]models.CoAuthor{jane}, false)
+	require.NoError(t, err)
+	assert.Empty(t, rewrites)
+}
+
+func TestCommits(t *testing.T) {
+	setupRepo(t, "first", "second", "third")
+	jane := models.CoAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+
+	rewrites, err := Commits("HEAD~2", []models.CoAuthor{jane}, false)
+	require.NoError(t, err)
+	require.Len(t, rewrites, 2)
+
+	assert.Equal(t, []string{
+		"third\n\nCo-authored-by: Jane Doe <jane@example.com>",
+		"second\n\nCo-authored-by: Jane Doe <jane@example.com>",
+		"first",
+	}, messages(t, "3"))
+
+	_, err = Commits("HEAD~1..HEAD~1", []models.CoAuthor{jane}, false)
+	assert.Error(t, err)
+}
+
+func TestCommitsRefusesPushed(t *testing.T) {
+	setupRepo(t, "first", "second", "third")
+	jane := models.CoAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+
+	// Pretend HEAD~1 was pushed
+	_, err := git.Run("update-ref", "refs/remotes/origin/main", "HEAD~1")
+	require.NoError(t, err)
+
+	_, err = Commits("HEAD~2", []models.CoAuthor{jane}, false)
+	assert.ErrorContains(t, err, "origin/main")
+
+	rewrites, err := Commits("HEAD~1", []models.CoAuthor{jane}, false)
+	require.NoError(t, err)
+	assert.Len(t, rewrites, 1)
+
+	// Rewriting the pushed commit also rewrites its descendant
+	rewrites, err = Commits("HEAD~2", []models.CoAuthor{jane}, true)
+	require.NoError(t, err)
+	assert.Len(t, rewrites, 2)
+}
+
+func TestCommitsRefusesPushedSideBranch(t *testing.T) {
+	setupRepo(t, "first")
+	jane := models.CoAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+
+	// A side branch is pushed and merged after an unpushed commit
+	for _, args := range [][]string{
+		{"checkout", "--quiet", "-b", "side"},
+		{"commit", "--quiet", "--allow-empty", "-m", "side"},
+		{"update-ref", "refs/remotes/origin/side", "HEAD"},
+		{"checkout", "--quiet", "-"},
+		{"commit", "--quiet", "--allow-empty", "-m", "second"},
+		{"merge", "--quiet", "--no-ff", "--no-edit", "side"},
+	} {
+		_, err := git.Run(args...)
+		require.NoError(t, err)
+	}
+
+	// The oldest commit of the range is not the pushed one
+	side, err := revParse("origin/side")
+	require.NoError(t, err)
+	commits, err := listCommits("HEAD~2..HEAD")
+	require.NoError(t, err)
+	require.Len(t, commits, 3)
+	assert.NotEqual(t, side, commits[0].hash)
+
+	_, err = Commits("HEAD~2", []models.CoAuthor{jane}, false)
+	assert.ErrorContains(t, err, "origin/side")
+}
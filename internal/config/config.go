@@ -3,152 +3,603 @@ package config
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/philippeckel/pair/internal/models"
+	"github.com/philippeckel/pair/internal/trailer"
 	"github.com/spf13/viper"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 var (
+	// ConfigPath forces a single roster file, set with --config. If it is empty,
+	// the roster is merged from all layers, see LoadConfig.
 	ConfigPath string
 	Config     models.Config
 )
 
-// GetDefaultConfigPath returns the default path for the config file,
-// prioritizing a local .pair.json if it exists
+// loaded remembers the file the roster was last loaded from, to save it back
+// without losing anything and to detect changes made by others in between
+var loaded struct {
+	path     string
+	data     []byte
+	exists   bool
+	version  int                // Version of the file before migrating
+	layered  bool               // Merged from several files, cannot be saved
+	document *object            // The whole file
+	entries  map[string]*object // The co-author entries by alias
+	merged   models.Config      // All layers, which new aliases must not clash with
+	// otherGroups maps the groups the other layers contribute to the merged
+	// roster to their file, otherAliases holds the aliases the other layers define
+	otherGroups  map[string]string
+	otherAliases map[string]bool
+}
+
+// GetConfigPath returns the roster file changes are written to: the file given
+// with --config, else the nearest .pair.json between the current directory and
+// the repository root, else ~/.pair.json
 func GetConfigPath() string {
-	// First check if there's a .pair.json file in the current directory
-	localConfig := ".pair.json"
-	if _, err := os.Stat(localConfig); err == nil {
-		// Local config exists, use it
-		return localConfig
+	if ConfigPath != "" {
+		return ConfigPath
+	}
+
+	candidates := sourcePaths()
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if candidates[i].layer != LayerRepository {
+			break
+		}
+		if _, err := os.Stat(candidates[i].path); err == nil {
+			return candidates[i].path
+		}
+	}
+
+	return userConfigPath()
+}
+
+// orderedKeys returns the keys of a JSON object in the order they appear in the file
+func orderedKeys(raw json.RawMessage) ([]string, error) {
+	var keys []string
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+
+	// Skip the opening brace
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("error parsing JSON tokens: %w", err)
 	}
 
-	// No local config, use the one in home directory
-	homeDir, err := os.UserHomeDir()
+	// Read all keys in order
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error reading JSON token: %w", err)
+		}
+		if key, ok := token.(string); ok {
+			keys = append(keys, key)
+
+			// Skip the value (it is decoded separately)
+			var v interface{}
+			if err := decoder.Decode(&v); err != nil {
+				return nil, fmt.Errorf("error skipping JSON value: %w", err)
+			}
+		}
+	}
+
+	return keys, nil
+}
+
+// layer is a parsed roster file
+type layer struct {
+	path       string
+	data       []byte
+	version    int // Version of the file, it is migrated to CurrentVersion
+	document   *object
+	entries    map[string]*object
+	aliases    []string                    // Aliases in file order
+	coAuthors  map[string]*models.CoAuthor // nil hides the alias of lower layers
+	groupNames []string                    // Group names in file order
+	groups     map[string][]string         // nil hides the group of lower layers
+	trailers   []string                    // Custom trailer keys
+	problems   []Problem                   // Everything wrong with the file on its own
+	positions  keyPositions                // Where the keys are, looked up for problems
+}
+
+// parseLayer reads and decodes a roster file, checking every entry on its own.
+// Groups are checked once all layers are merged. The first error found in the
+// file is returned, with its location.
+func parseLayer(path string) (*layer, error) {
+	l, err := readLayer(path)
 	if err != nil {
-		// If we can't get home dir, use current directory as fallback
-		return localConfig
+		return nil, err
 	}
-	return filepath.Join(homeDir, ".pair.json")
+
+	for _, problem := range l.problems {
+		if problem.Severity == SeverityError {
+			return nil, problem
+		}
+	}
+	return l, nil
 }
 
-// LoadConfig loads and parses the config file
-func LoadConfig() error {
-	data, err := os.ReadFile(ConfigPath)
+// readLayer reads and decodes a roster file, recording every problem instead of
+// stopping at the first one. It only fails if the file cannot be read.
+func readLayer(path string) (*layer, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("could not read config file: %w", err)
+		return nil, fmt.Errorf("could not read config file: %w", err)
 	}
 
-	// First parse the structure to get the raw JSON
-	var jsonData struct {
-		CoAuthors json.RawMessage `json:"coauthors"`
+	l := &layer{
+		path:      path,
+		data:      data,
+		entries:   make(map[string]*object),
+		coAuthors: make(map[string]*models.CoAuthor),
+		groups:    make(map[string][]string),
 	}
-	if err := json.Unmarshal(data, &jsonData); err != nil {
-		return fmt.Errorf("could not parse config file: %w", err)
+
+	if l.reportDuplicates() {
+		return l, nil
 	}
 
-	// We'll decode the coauthors map while preserving order
-	var orderedAliases []string
-	var tempMap map[string]struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
+	// YAML and TOML rosters are read as the equivalent JSON
+	if data, err = toJSON(data, FormatOf(path)); err != nil {
+		l.syntaxProblem(err)
+		return l, nil
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		l.syntaxProblem(err)
+		return l, nil
+	}
+	if data, l.version, err = migrate(data); err != nil {
+		l.report(SeverityError, []string{"version"}, 0, "could not parse config file: %v", err)
+		return l, nil
 	}
 
-	// Decode the map while capturing order
-	decoder := json.NewDecoder(bytes.NewReader(jsonData.CoAuthors))
-	if err := decoder.Decode(&tempMap); err != nil {
-		return fmt.Errorf("could not decode coauthors map: %w", err)
+	// First split the document into its sections
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		l.syntaxProblem(err)
+		return l, nil
+	}
+	if l.document, err = parseObject(data); err != nil {
+		l.syntaxProblem(err)
+		return l, nil
 	}
 
-	// Now parse again to get the keys in order
-	// This part relies on the decoder preserving token order
-	decoder = json.NewDecoder(bytes.NewReader(jsonData.CoAuthors))
+	if raw := sections["trailers"]; !isNull(raw) {
+		if err := json.Unmarshal(raw, &l.trailers); err != nil {
+			l.report(SeverityError, []string{"trailers"}, 0, "trailers must be a list of trailer keys")
+		}
+		for i, key := range l.trailers {
+			if err := validateTrailerKey(key); err != nil {
+				l.report(SeverityError, []string{"trailers", strconv.Itoa(i)}, 0, "%v", err)
+			}
+		}
+	}
 
-	// Skip the opening brace
-	_, err = decoder.Token()
+	if raw := sections["coauthors"]; !isNull(raw) {
+		l.parseCoAuthors(raw)
+	}
+
+	if raw := sections["groups"]; !isNull(raw) {
+		l.parseGroups(raw)
+	}
+
+	return l, nil
+}
+
+// isNull reports whether a section is missing or null
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(raw) == "null"
+}
+
+// parseCoAuthors decodes the coauthors section of the layer in declaration order
+func (l *layer) parseCoAuthors(raw json.RawMessage) {
+	// We'll decode the coauthors map while preserving order
+	var rawEntries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &rawEntries); err != nil {
+		l.report(SeverityError, []string{"coauthors"}, 0, "coauthors must map aliases to co-authors")
+		return
+	}
+
+	// Now parse again to get the keys in order
+	orderedAliases, err := orderedKeys(raw)
 	if err != nil {
-		return fmt.Errorf("error parsing JSON tokens: %w", err)
+		l.report(SeverityError, []string{"coauthors"}, 0, "%v", err)
+		return
 	}
 
-	// Read all keys in order
-	for decoder.More() {
-		token, err := decoder.Token()
-		if err != nil {
-			return fmt.Errorf("error reading JSON token: %w", err)
+	for _, alias := range orderedAliases {
+		key := []string{"coauthors", alias}
+		l.aliases = append(l.aliases, alias)
+
+		// null hides an entry of a lower layer
+		if string(rawEntries[alias]) == "null" {
+			l.coAuthors[alias] = nil
+			continue
 		}
-		if key, ok := token.(string); ok {
-			orderedAliases = append(orderedAliases, key)
 
-			// Skip the value (we already have it in tempMap)
-			var v interface{}
-			if err := decoder.Decode(&v); err != nil {
-				return fmt.Errorf("error skipping JSON value: %w", err)
+		if err := validateAliasName(alias); err != nil {
+			l.report(SeverityWarning, key, 0, "%v, it cannot be selected by alias; rename it with 'pair config rename'", err)
+		}
+
+		var details struct {
+			Name   string         `json:"name"`
+			Email  string         `json:"email"`
+			Emails []models.Email `json:"emails"`
+		}
+		if err := json.Unmarshal(rawEntries[alias], &details); err != nil {
+			l.report(SeverityError, key, 0, "could not decode co-author '%s': %v", alias, err)
+			continue
+		}
+
+		coauthor := models.CoAuthor{
+			Name:   details.Name,
+			Email:  details.Email,
+			Emails: details.Emails,
+			Alias:  alias,
+		}
+
+		for _, problem := range coauthor.FieldErrors() {
+			field := append(key[:len(key):len(key)], strings.Split(problem.Field, ".")...)
+			if problem.Warning {
+				l.report(SeverityWarning, field, 0, "co-author '%s': %v", alias, problem)
+				continue
 			}
+			l.report(SeverityError, field, 0, "invalid co-author '%s': %v", alias, problem)
+		}
+		// Names are shown and credited the way trailers write them
+		coauthor.Name = trailer.SanitizeName(coauthor.Name)
+		l.coAuthors[alias] = &coauthor
+
+		// Remember the fields of the entry in their order
+		if entry, err := parseObject(rawEntries[alias]); err == nil {
+			l.entries[alias] = entry
 		}
 	}
+}
 
-	// Now build our config data in the correct order
-	Config.CoAuthorsMap = make(map[string]models.CoAuthor)
-	Config.CoAuthors = make([]models.CoAuthor, 0, len(tempMap))
+// parseGroups decodes the groups section of the layer in declaration order
+func (l *layer) parseGroups(raw json.RawMessage) {
+	if err := json.Unmarshal(raw, &l.groups); err != nil {
+		l.report(SeverityError, []string{"groups"}, 0, "groups must map names to lists of aliases")
+		return
+	}
 
-	for _, alias := range orderedAliases {
-		details := tempMap[alias]
-		coauthor := models.CoAuthor{
-			Name:  details.Name,
-			Email: details.Email,
-			Alias: alias,
+	var err error
+	if l.groupNames, err = orderedKeys(raw); err != nil {
+		l.report(SeverityError, []string{"groups"}, 0, "%v", err)
+	}
+}
+
+// LoadConfig loads the roster. With ConfigPath set only that file is read.
+// Otherwise the system, user and repository rosters are merged, see Sources.
+func LoadConfig() error {
+	if ConfigPath != "" {
+		return loadFile(ConfigPath, LayerFile, nil, false)
+	}
+	return loadLayers()
+}
+
+// LoadConfigForWrite loads only the roster file changes are written to, see
+// GetConfigPath, so that saving does not copy entries of other layers into it.
+// Groups of the file may refer to co-authors of the other layers. A missing
+// file is an empty roster, which saving creates.
+func LoadConfigForWrite() error {
+	if ConfigPath != "" {
+		return loadFile(ConfigPath, LayerFile, nil, true)
+	}
+
+	var merged models.Config
+	var sources []Source
+	if err := loadLayers(); err == nil {
+		merged, sources = Config, Sources
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	path := GetConfigPath()
+	layerName := LayerUser
+	for _, candidate := range sourcePaths() {
+		if candidate.path == path {
+			layerName = candidate.layer
 		}
+	}
+	if err := loadFile(path, layerName, merged.CoAuthorsMap, true); err != nil {
+		return err
+	}
+	loaded.merged = merged
 
-		if err := coauthor.Validate(); err != nil {
-			return fmt.Errorf("invalid co-author '%s': %w", alias, err)
+	// Later layers replace the groups of earlier ones
+	for _, source := range sources {
+		if source.Path == path {
+			continue
+		}
+		for _, name := range source.Groups {
+			loaded.otherGroups[name] = source.Path
+		}
+		for _, name := range source.Removed {
+			delete(loaded.otherGroups, name)
 		}
+		for _, alias := range source.CoAuthors {
+			loaded.otherAliases[alias] = true
+		}
+	}
+	return nil
+}
 
-		Config.CoAuthorsMap[alias] = coauthor
-		Config.CoAuthors = append(Config.CoAuthors, coauthor)
+// loadFile loads the roster from a single file, which can be saved back. Group
+// members may also refer to the co-authors in others. With create, a missing
+// file is loaded as an empty roster.
+func loadFile(path, layerName string, others map[string]models.CoAuthor, create bool) error {
+	loaded.path, loaded.data, loaded.exists, loaded.layered = path, nil, false, false
+	loaded.document, loaded.entries, loaded.merged = nil, make(map[string]*object), models.Config{}
+	loaded.otherGroups, loaded.otherAliases = make(map[string]string), make(map[string]bool)
+	Sources = nil
+
+	l, err := parseLayer(path)
+	switch {
+	case err == nil:
+		loaded.data, loaded.exists, loaded.version = l.data, true, l.version
+		loaded.document, loaded.entries = l.document, l.entries
+	case create && errors.Is(err, fs.ErrNotExist):
+		l = &layer{
+			path:      path,
+			entries:   make(map[string]*object),
+			coAuthors: make(map[string]*models.CoAuthor),
+			groups:    make(map[string][]string),
+		}
+		loaded.version = CurrentVersion
+	default:
+		return err
 	}
 
+	return merge([]*layer{l}, []string{layerName}, others)
+}
+
+// merge combines the layers, lowest precedence first, and checks the groups of
+// the result, see combine
+func merge(layers []*layer, layerNames []string, others map[string]models.CoAuthor) error {
+	origins := combine(layers, layerNames)
+	if problems := groupProblems(origins, others); len(problems) > 0 {
+		return problems[0]
+	}
 	return nil
 }
 
-// SaveConfig saves the config back to the file
+// origins are the layers the co-authors and groups of the merged roster were taken from
+type origins struct {
+	coAuthors map[string]*layer
+	groups    map[string]*layer
+}
+
+// combine merges the layers, lowest precedence first. An entry replaces the
+// whole entry with the same alias of lower layers, keeping its position; new
+// aliases are appended. Groups are merged the same way.
+func combine(layers []*layer, layerNames []string) origins {
+	Config.CoAuthorsMap = make(map[string]models.CoAuthor)
+	Config.CoAuthors = nil
+	Config.Groups = make(map[string][]string)
+	Config.GroupNames = nil
+	Config.Trailers = nil
+	Sources = nil
+
+	from := origins{coAuthors: make(map[string]*layer), groups: make(map[string]*layer)}
+
+	var aliases []string
+	for i, l := range layers {
+		source := Source{Path: l.path, Layer: layerNames[i]}
+		Config.Trailers = append(Config.Trailers, l.trailers...)
+
+		for _, alias := range l.aliases {
+			author := l.coAuthors[alias]
+			_, exists := Config.CoAuthorsMap[alias]
+
+			switch {
+			case author == nil:
+				source.Removed = append(source.Removed, alias)
+				if exists {
+					delete(Config.CoAuthorsMap, alias)
+					delete(from.coAuthors, alias)
+					aliases = without(aliases, alias)
+				}
+			default:
+				source.CoAuthors = append(source.CoAuthors, alias)
+				if !exists {
+					aliases = append(aliases, alias)
+				}
+				Config.CoAuthorsMap[alias] = *author
+				from.coAuthors[alias] = l
+			}
+		}
+
+		for _, name := range l.groupNames {
+			members := l.groups[name]
+			_, exists := Config.Groups[name]
+
+			switch {
+			case members == nil:
+				source.Removed = append(source.Removed, name)
+				if exists {
+					delete(Config.Groups, name)
+					delete(from.groups, name)
+					Config.GroupNames = without(Config.GroupNames, name)
+				}
+			default:
+				source.Groups = append(source.Groups, name)
+				if !exists {
+					Config.GroupNames = append(Config.GroupNames, name)
+				}
+				Config.Groups[name] = members
+				from.groups[name] = l
+			}
+		}
+
+		Sources = append(Sources, source)
+	}
+
+	Config.CoAuthors = make([]models.CoAuthor, 0, len(aliases))
+	for _, alias := range aliases {
+		Config.CoAuthors = append(Config.CoAuthors, Config.CoAuthorsMap[alias])
+	}
+
+	return from
+}
+
+// without returns list without value
+func without(list []string, value string) []string {
+	var result []string
+	for _, item := range list {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// groupProblems checks that every group member of the merged roster refers to a
+// configured co-author, either in the merged roster or in others
+func groupProblems(from origins, others map[string]models.CoAuthor) []Problem {
+	var problems []Problem
+	for _, name := range Config.GroupNames {
+		l := from.groups[name]
+		key := []string{"groups", name}
+
+		if _, exists := Config.CoAuthorsMap[name]; exists {
+			problems = append(problems, l.problemAt(SeverityError, key, 0,
+				fmt.Sprintf("invalid group '%s': name is already used as a co-author alias", name)))
+		}
+
+		members := Config.Groups[name]
+		if len(members) == 0 {
+			problems = append(problems, l.problemAt(SeverityError, key, 0,
+				fmt.Sprintf("invalid group '%s': group has no members", name)))
+		}
+		for i, member := range members {
+			_, exists := Config.CoAuthorsMap[member]
+			if _, other := others[member]; !exists && !other {
+				problems = append(problems, l.problemAt(SeverityError, append(key, strconv.Itoa(i)), 0,
+					fmt.Sprintf("invalid group '%s': no co-author found with alias '%s'", name, member)))
+			}
+		}
+	}
+
+	return problems
+}
+
+// SaveConfig writes the roster back to the file it was loaded from, keeping the
+// order of the entries and everything pair does not know about. The file is
+// replaced atomically while holding an advisory lock, and saving fails if another
+// process changed the file since it was loaded.
 func SaveConfig() error {
-	// Convert our internal structure back to the expected JSON format
-	tempConfig := struct {
-		CoAuthorsMap map[string]struct {
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		} `json:"coauthors"`
-	}{
-		CoAuthorsMap: make(map[string]struct {
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		}),
-	}
-
-	for alias, coauthor := range Config.CoAuthorsMap {
-		tempConfig.CoAuthorsMap[alias] = struct {
-			Name  string `json:"name"`
-			Email string `json:"email"`
-		}{
-			Name:  coauthor.Name,
-			Email: coauthor.Email,
-		}
-	}
-
-	data, err := json.MarshalIndent(tempConfig, "", "  ")
+	if loaded.layered {
+		return fmt.Errorf("the roster was merged from several files and cannot be saved as a whole")
+	}
+	// Lock and replace the file a symbolic link points to, keeping the link
+	path := resolveLink(loaded.path)
+
+	data, err := encodeFile(path)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := LockDir(path)
 	if err != nil {
-		return fmt.Errorf("error encoding config: %w", err)
+		return err
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(path)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+	if exists != loaded.exists || !bytes.Equal(current, loaded.data) {
+		return fmt.Errorf("config file %s was changed by another process, please try again", path)
 	}
 
-	if err := os.WriteFile(ConfigPath, data, 0644); err != nil {
+	if err := WriteFileAtomic(path, data); err != nil {
 		return fmt.Errorf("error writing config file: %w", err)
 	}
 
+	loaded.data, loaded.exists, loaded.version = data, true, CurrentVersion
 	return nil
 }
 
+// encodeFile encodes the loaded roster in the format of path
+func encodeFile(path string) ([]byte, error) {
+	data, err := encodeDocument(loaded.document, loaded.entries)
+	if err != nil {
+		return nil, err
+	}
+	format := FormatOf(path)
+	if format == FormatTOML && loaded.exists && tomlHasComments(loaded.data) {
+		// TOML is written from scratch, which would lose them
+		return nil, fmt.Errorf("config file %s has comments that saving would remove, edit it by hand instead", path)
+	}
+	if data, err = EncodeFile(path, data); err != nil {
+		return nil, err
+	}
+	if format == FormatYAML && loaded.exists {
+		data = keepYAMLLayout(loaded.data, data)
+	}
+	return data, nil
+}
+
+// resolveLink follows symbolic links to the file they point to, which may not
+// exist yet, e.g. a roster kept in a dotfiles repository
+func resolveLink(path string) string {
+	for i := 0; i < 255; i++ {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return path
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return path
+}
+
+// WriteFileAtomic writes data to a temporary file next to path and renames it
+// over path, so that readers never see a partially written file. The mode of an
+// existing file is kept, and a symbolic link at path keeps pointing to the file.
+func WriteFileAtomic(path string, data []byte) error {
+	path = resolveLink(path)
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
 func InitViper() error {
 	// Get home directory for config file
 	home, err := os.UserHomeDir()
@@ -174,9 +625,13 @@ func InitViper() error {
 	// Set default values
 	viper.SetDefault("no_color", false) // Default to using colors
 	viper.SetDefault("default_template_path", filepath.Join(home, ".config", "pair", "git_commit_template"))
+	viper.SetDefault("scope", "global") // Write commit.template to the global git config
+	viper.SetDefault("output", "table") // Human-readable tables
 
-	// Check environment variables that match the config keys
-	// This will override values from config file
+	// Environment variables such as PAIR_SCOPE override the config file. The
+	// prefix keeps generic ones like OUTPUT from other tools out.
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	viper.AutomaticEnv()
 
 	// Override with environment variables like NO_COLOR
@@ -196,6 +651,34 @@ func InitViper() error {
 	return nil
 }
 
+// EnvPrefix is the prefix of the environment variables overriding settings, e.g. PAIR_SCOPE
+const EnvPrefix = "PAIR"
+
+// SettingKeys lists the keys of the settings file pair knows about
+var SettingKeys = []string{"no_color", "debug", "default_template_path", "scope", "output", "trailers"}
+
+// ReadSettings reads the settings file InitViper found once more and returns
+// its path and the keys it sets. The path is empty if there is no settings file.
+func ReadSettings() (string, []string, error) {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		return "", nil, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if filepath.Ext(path) == "" {
+		v.SetConfigType("yaml")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, nil
+		}
+		return path, nil, err
+	}
+	return path, v.AllKeys(), nil
+}
+
 // IsColorDisabled returns true if colors should be disabled
 func IsColorDisabled() bool {
 	return viper.GetBool("no_color")
@@ -210,3 +693,13 @@ func GetDebug() bool {
 func GetTemplatePath() string {
 	return viper.GetString("default_template_path")
 }
+
+// GetScope returns the configured commit template scope (global, local or worktree)
+func GetScope() string {
+	return viper.GetString("scope")
+}
+
+// GetOutput returns the configured output format (table, json, yaml, csv or plain)
+func GetOutput() string {
+	return viper.GetString("output")
+}
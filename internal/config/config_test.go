@@ -0,0 +1,211 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigGroups(t *testing.T) {
+	tests := []struct {
+		name           string
+		groups         string
+		expectErrMatch string
+		expectNames    []string
+	}{
+		{
+			name:        "groups keep declaration order",
+			groups:      `{"zeta": ["jane"], "alpha": ["john", "jane"]}`,
+			expectNames: []string{"zeta", "alpha"},
+		},
+		{
+			name:        "no groups",
+			groups:      `null`,
+			expectNames: nil,
+		},
+		{
+			name:           "unknown member",
+			groups:         `{"squad": ["jane", "nobody"]}`,
+			expectErrMatch: "invalid group 'squad': no co-author found with alias 'nobody'",
+		},
+		{
+			name:           "group named like an alias",
+			groups:         `{"jane": ["john"]}`,
+			expectErrMatch: "name is already used as a co-author alias",
+		},
+		{
+			name:           "empty group",
+			groups:         `{"squad": []}`,
+			expectErrMatch: "group has no members",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ConfigPath = filepath.Join(t.TempDir(), ".pair.json")
+			data := `{
+  "coauthors": {
+    "jane": {"name": "Jane Doe", "email": "jane@example.com"},
+    "john": {"name": "John Doe", "email": "john@example.com"}
+  },
+  "groups": ` + tc.groups + `
+}`
+			require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+
+			err := LoadConfig()
+			if tc.expectErrMatch != "" {
+				assert.ErrorContains(t, err, tc.expectErrMatch)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectNames, Config.GroupNames)
+		})
+	}
+}
+
+func TestAlternateEmails(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), ".pair.json")
+	data := `{"coauthors": {"jane": {"name": "Jane Doe", "email": "jane@example.com", "emails": ["jane@personal.example.org"]}}}`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+
+	require.NoError(t, LoadConfig())
+	assert.Equal(t, []models.Email{{Address: "jane@personal.example.org"}}, Config.CoAuthorsMap["jane"].Emails)
+
+	// Alternate emails survive a save
+	require.NoError(t, SaveConfig())
+	require.NoError(t, LoadConfig())
+	assert.Equal(t, []string{"jane@example.com", "jane@personal.example.org"}, Config.CoAuthors[0].AllEmails())
+
+	data = `{"coauthors": {"jane": {"name": "Jane Doe", "email": "jane@example.com", "emails": ["jane"]}}}`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+	assert.ErrorContains(t, LoadConfig(), "alternate email 'jane' must contain '@' character")
+}
+
+func TestSaveConfigKeepsOrderAndUnknownFields(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), ".pair.json")
+	data := `{
+  "$comment": "team roster",
+  "coauthors": {
+    "zoe": {"email": "zoe@example.com", "name": "Zoe", "team": "web"},
+    "adam": {"name": "Adam", "email": "adam@example.com"}
+  },
+  "groups": {"web": ["zoe"]},
+  "extra": [1, 2]
+}`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0600))
+	require.NoError(t, LoadConfig())
+
+	require.NoError(t, AddCoAuthor("bob", models.CoAuthor{Name: "Bob", Email: "bob@example.com"}))
+	require.NoError(t, RenameCoAuthor("zoe", "zo"))
+	require.NoError(t, SaveConfig())
+
+	saved, err := os.ReadFile(ConfigPath)
+	require.NoError(t, err)
+	// Saving also upgrades the roster to the current version
+	assert.Equal(t, `{
+  "version": 1,
+  "$comment": "team roster",
+  "coauthors": {
+    "zo": {
+      "email": "zoe@example.com",
+      "name": "Zoe",
+      "team": "web"
+    },
+    "adam": {
+      "name": "Adam",
+      "email": "adam@example.com"
+    },
+    "bob": {
+      "name": "Bob",
+      "email": "bob@example.com"
+    }
+  },
+  "groups": {
+    "web": [
+      "zo"
+    ]
+  },
+  "extra": [
+    1,
+    2
+  ]
+}
+`, string(saved))
+
+	info, err := os.Stat(ConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Saving twice in a row works, the second save sees its own write
+	require.NoError(t, SaveConfig())
+}
+
+func TestSaveConfigRefusesConcurrentChanges(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), ".pair.json")
+	data := `{"coauthors": {"jane": {"name": "Jane Doe", "email": "jane@example.com"}}}`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+	require.NoError(t, LoadConfig())
+
+	// Another terminal adds a co-author in the meantime
+	changed := `{"coauthors": {"jane": {"name": "Jane Doe", "email": "jane@example.com"}, "sam": {"name": "Sam", "email": "sam@example.com"}}}`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(changed), 0644))
+
+	require.NoError(t, AddCoAuthor("john", models.CoAuthor{Name: "John Doe", Email: "john@example.com"}))
+	assert.ErrorContains(t, SaveConfig(), "was changed by another process")
+
+	saved, err := os.ReadFile(ConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, changed, string(saved))
+}
+
+func TestSaveConfigKeepsSymbolicLink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "pair.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(target), 0755))
+	data := `{"coauthors": {"jane": {"name": "Jane Doe", "email": "jane@example.com"}}}`
+	require.NoError(t, os.WriteFile(target, []byte(data), 0600))
+
+	ConfigPath = filepath.Join(dir, ".pair.json")
+	if err := os.Symlink(filepath.Join("dotfiles", "pair.json"), ConfigPath); err != nil {
+		t.Skipf("symbolic links are not supported: %v", err)
+	}
+	require.NoError(t, LoadConfig())
+
+	require.NoError(t, AddCoAuthor("john", models.CoAuthor{Name: "John Doe", Email: "john@example.com"}))
+	require.NoError(t, SaveConfig())
+
+	info, err := os.Lstat(ConfigPath)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "the link must be kept")
+
+	saved, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), "john@example.com")
+	info, err = os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Saving again through the link sees its own change
+	require.NoError(t, RenameCoAuthor("john", "jd"))
+	require.NoError(t, SaveConfig())
+}
+
+func TestEnvironmentVariablesNeedPrefix(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Cleanup(viper.Reset)
+
+	// Generic variables of other tools are ignored
+	t.Setenv("OUTPUT", "dist")
+	t.Setenv("SCOPE", "repo")
+	t.Setenv("PAIR_OUTPUT", "json")
+	require.NoError(t, InitViper())
+
+	assert.Equal(t, "json", viper.GetString("output"))
+	assert.Equal(t, "global", GetScope())
+}
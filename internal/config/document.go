@@ -0,0 +1,201 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// object is a JSON object that keeps the order of its keys and the raw values of
+// keys pair does not know about, so that saving does not lose or reorder anything
+type object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// newObject returns an empty object
+func newObject() *object {
+	return &object{values: make(map[string]json.RawMessage)}
+}
+
+// parseObject decodes a JSON object, remembering the order of its keys
+func parseObject(raw json.RawMessage) (*object, error) {
+	o := newObject()
+	if err := json.Unmarshal(raw, &o.values); err != nil {
+		return nil, err
+	}
+
+	keys, err := orderedKeys(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		// A repeated key keeps its first position, like its value keeps the last
+		if !seen[key] {
+			seen[key] = true
+			o.keys = append(o.keys, key)
+		}
+	}
+	return o, nil
+}
+
+// get returns the raw value of key, or nil if it is not set
+func (o *object) get(key string) json.RawMessage {
+	return o.values[key]
+}
+
+// set replaces the value of key in place or appends the key if it is new
+func (o *object) set(key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = raw
+	return nil
+}
+
+// delete removes key
+func (o *object) delete(key string) {
+	if _, exists := o.values[key]; !exists {
+		return
+	}
+	delete(o.values, key)
+	for i, candidate := range o.keys {
+		if candidate == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON writes the keys in their order
+func (o *object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(o.values[key])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// encodeDocument merges the roster into the document it was loaded from. Keys and
+// fields unknown to pair are kept where they were, co-authors and groups are written
+// in roster order and entries keep the order of their fields.
+func encodeDocument(doc *object, entries map[string]*object) ([]byte, error) {
+	if doc == nil {
+		doc = newObject()
+		if err := doc.set("version", CurrentVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	coAuthors := newObject()
+	for _, author := range Config.CoAuthors {
+		entry, ok := entries[author.Alias]
+		if !ok {
+			entry = newObject()
+		}
+
+		if err := entry.set("name", author.Name); err != nil {
+			return nil, err
+		}
+		if err := entry.set("email", author.Email); err != nil {
+			return nil, err
+		}
+		if len(author.Emails) > 0 {
+			if err := entry.set("emails", author.Emails); err != nil {
+				return nil, err
+			}
+		} else {
+			entry.delete("emails")
+		}
+
+		if err := coAuthors.set(author.Alias, entry); err != nil {
+			return nil, err
+		}
+	}
+	if err := doc.set("coauthors", withRemovals(doc.get("coauthors"), coAuthors)); err != nil {
+		return nil, err
+	}
+
+	groups := newObject()
+	for _, name := range Config.GroupNames {
+		if err := groups.set(name, Config.Groups[name]); err != nil {
+			return nil, err
+		}
+	}
+	groups = withRemovals(doc.get("groups"), groups)
+	if len(groups.keys) > 0 {
+		if err := doc.set("groups", groups); err != nil {
+			return nil, err
+		}
+	} else {
+		doc.delete("groups")
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding config: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// withRemovals adds the keys that previous, the section as it was loaded, sets
+// to null to the rebuilt section. They remove entries of lower layers, which
+// the roster does not hold, and keep their place before the entries that
+// followed them.
+func withRemovals(previous json.RawMessage, section *object) *object {
+	if previous == nil {
+		return section
+	}
+	old, err := parseObject(previous)
+	if err != nil {
+		// Rosters listing the co-authors instead have nothing to keep
+		return section
+	}
+
+	var removals []string
+	position := make(map[string]int)
+	for i, key := range old.keys {
+		position[key] = i
+		if _, kept := section.values[key]; !kept && bytes.Equal(bytes.TrimSpace(old.values[key]), []byte("null")) {
+			removals = append(removals, key)
+		}
+	}
+	if len(removals) == 0 {
+		return section
+	}
+
+	result := newObject()
+	addRemovals := func(before int) {
+		for len(removals) > 0 && position[removals[0]] < before {
+			result.keys = append(result.keys, removals[0])
+			result.values[removals[0]] = json.RawMessage("null")
+			removals = removals[1:]
+		}
+	}
+	for _, key := range section.keys {
+		if i, ok := position[key]; ok {
+			addRemovals(i)
+		}
+		result.keys = append(result.keys, key)
+		result.values[key] = section.values[key]
+	}
+	addRemovals(len(old.keys))
+	return result
+}
@@ -0,0 +1,818 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// Roster file formats, detected by the file extension
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// Formats lists the supported roster file formats
+var Formats = []string{FormatJSON, FormatYAML, FormatTOML}
+
+// formatExtensions maps file extensions to formats, in the order roster files
+// are looked up in a directory
+var formatExtensions = []struct {
+	ext    string
+	format string
+}{
+	{".json", FormatJSON},
+	{".yaml", FormatYAML},
+	{".yml", FormatYAML},
+	{".toml", FormatTOML},
+}
+
+// FormatOf returns the format of a roster file by its extension, JSON if unknown
+func FormatOf(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, candidate := range formatExtensions {
+		if candidate.ext == ext {
+			return candidate.format
+		}
+	}
+	return FormatJSON
+}
+
+// toJSON converts a roster file in the given format to JSON, keeping the
+// order of all keys
+func toJSON(data []byte, format string) ([]byte, error) {
+	var value interface{}
+	var err error
+
+	switch format {
+	case FormatJSON:
+		return data, nil
+	case FormatYAML:
+		value, err = decodeYAML(data)
+	case FormatTOML:
+		value, err = decodeTOML(data)
+	default:
+		return nil, fmt.Errorf("unsupported roster format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// fromJSON converts a JSON roster to the given format, keeping the order of all keys
+func fromJSON(data []byte, format string) ([]byte, error) {
+	if format == FormatJSON {
+		return data, nil
+	}
+
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatYAML:
+		return encodeYAML(yamlNode(value))
+	case FormatTOML:
+		root, ok := value.(*tree)
+		if !ok {
+			return nil, fmt.Errorf("a TOML roster must be a table")
+		}
+		var buf bytes.Buffer
+		if err := writeTOMLTable(&buf, nil, root); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported roster format '%s'", format)
+}
+
+// EncodeFile converts a roster written as JSON into the format of path, e.g.
+// YAML for a .yaml file
+func EncodeFile(path string, data []byte) ([]byte, error) {
+	encoded, err := fromJSON(data, FormatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not encode config file %s: %w", path, err)
+	}
+	return encoded, nil
+}
+
+// ConvertFile rewrites the roster file at path in another format next to it,
+// with the extension of the format, and removes the original. It returns the
+// path of the new file.
+func ConvertFile(path, format string) (string, error) {
+	if !slices.Contains(Formats, format) {
+		return "", fmt.Errorf("unsupported roster format '%s', use one of %s", format, strings.Join(Formats, ", "))
+	}
+	if FormatOf(path) == format {
+		return "", fmt.Errorf("%s is already a %s file", path, strings.ToUpper(format))
+	}
+
+	// Refuse to convert a roster pair cannot read
+	l, err := parseLayer(path)
+	if err != nil {
+		return "", err
+	}
+
+	target := strings.TrimSuffix(path, filepath.Ext(path)) + "." + format
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("%s already exists", target)
+	}
+
+	data, err := toJSON(l.data, FormatOf(path))
+	if err != nil {
+		return "", err
+	}
+	if data, err = fromJSON(data, format); err != nil {
+		return "", fmt.Errorf("could not convert %s: %w", path, err)
+	}
+	if format == FormatJSON {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, data, "", "  "); err != nil {
+			return "", err
+		}
+		data = append(indented.Bytes(), '\n')
+	}
+
+	unlock, err := LockDir(path)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("could not read config file: %w", err)
+	}
+	if !bytes.Equal(current, l.data) {
+		return "", fmt.Errorf("config file %s was changed by another process, please try again", path)
+	}
+
+	if err := WriteFileAtomic(target, data); err != nil {
+		return "", fmt.Errorf("error writing config file: %w", err)
+	}
+	// Keep the permissions of the original
+	if info, err := os.Stat(path); err == nil {
+		if err := os.Chmod(target, info.Mode().Perm()); err != nil {
+			return "", err
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("could not remove %s: %w", path, err)
+	}
+	return target, nil
+}
+
+// tree is a map that remembers the order of its keys
+type tree struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newTree() *tree {
+	return &tree{values: make(map[string]interface{})}
+}
+
+// add appends a key, failing if it is already present
+func (t *tree) add(key string, value interface{}) error {
+	if _, exists := t.values[key]; exists {
+		return fmt.Errorf("duplicate key '%s'", key)
+	}
+	t.keys = append(t.keys, key)
+	t.values[key] = value
+	return nil
+}
+
+// setFirst sets key, moving it in front of all other keys
+func (t *tree) setFirst(key string, value interface{}) {
+	t.remove(key)
+	t.keys = append([]string{key}, t.keys...)
+	t.values[key] = value
+}
+
+// remove deletes key
+func (t *tree) remove(key string) {
+	if _, exists := t.values[key]; !exists {
+		return
+	}
+	delete(t.values, key)
+	for i, existing := range t.keys {
+		if existing == key {
+			t.keys = append(t.keys[:i], t.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON writes the keys in their order
+func (t *tree) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range t.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		encodedValue, err := json.Marshal(t.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encodedKey)
+		buf.WriteByte(':')
+		buf.Write(encodedValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered decodes JSON into trees, slices and json.Number, string, bool
+// or nil values
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var decode func() (interface{}, error)
+	decode = func() (interface{}, error) {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			t := newTree()
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decode()
+				if err != nil {
+					return nil, err
+				}
+				if err := t.add(key.(string), value); err != nil {
+					return nil, err
+				}
+			}
+			_, err := dec.Token()
+			return t, err
+		case json.Delim('['):
+			list := []interface{}{}
+			for dec.More() {
+				value, err := decode()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, value)
+			}
+			_, err := dec.Token()
+			return list, err
+		}
+		return token, nil
+	}
+
+	return decode()
+}
+
+// decodeYAML decodes a YAML document into trees, slices and scalars
+func decodeYAML(data []byte) (interface{}, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return newTree(), nil
+	}
+	return decodeYAMLNode(doc.Content[0])
+}
+
+// decodeYAMLNode decodes a YAML node into trees, slices and scalars
+func decodeYAMLNode(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		return decodeYAMLNode(node.Alias)
+	case yaml.MappingNode:
+		t := newTree()
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := decodeYAMLNode(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if err := t.add(node.Content[i].Value, value); err != nil {
+				return nil, fmt.Errorf("line %d: %w", node.Content[i].Line, err)
+			}
+		}
+		return t, nil
+	case yaml.SequenceNode:
+		list := []interface{}{}
+		for _, item := range node.Content {
+			value, err := decodeYAMLNode(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// encodeYAML writes a YAML document with the indentation of pair's rosters
+func encodeYAML(node *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// keepYAMLLayout carries the comments and the layout of the original YAML
+// roster over to its updated rendering. Nodes whose value did not change are
+// kept as they were, e.g. inline mappings and quoted strings, and changed
+// ones keep their comments. The updated rendering is returned unchanged if the
+// result would not hold the same values, e.g. because of anchors.
+func keepYAMLLayout(original, updated []byte) []byte {
+	var before, after yaml.Node
+	if err := yaml.Unmarshal(original, &before); err != nil || len(before.Content) == 0 {
+		return updated
+	}
+	if err := yaml.Unmarshal(updated, &after); err != nil || len(after.Content) == 0 {
+		return updated
+	}
+
+	root := before.Content[0]
+	merged := mergeYAMLNode(root, after.Content[0])
+
+	// A comment heading the file stays on top when a key such as version is inserted first
+	if merged.Kind == yaml.MappingNode && root.Kind == yaml.MappingNode && len(root.Content) > 0 &&
+		len(merged.Content) > 0 && merged.Content[0] != root.Content[0] {
+		merged.Content[0].HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+	}
+	before.Content[0] = merged
+
+	data, err := encodeYAML(&before)
+	if err != nil || !sameYAMLDocument(data, updated) {
+		return updated
+	}
+	return data
+}
+
+// sameYAMLDocument reports whether both documents hold the same values in the same order
+func sameYAMLDocument(a, b []byte) bool {
+	valueA, errA := toJSON(a, FormatYAML)
+	valueB, errB := toJSON(b, FormatYAML)
+	return errA == nil && errB == nil && bytes.Equal(valueA, valueB)
+}
+
+// mergeYAMLNode returns the original node if its value equals the updated one,
+// otherwise the updated node with the comments, anchor and style of the original
+func mergeYAMLNode(original, updated *yaml.Node) *yaml.Node {
+	if sameYAMLValue(original, updated) {
+		return original
+	}
+
+	merged := *updated
+	merged.HeadComment, merged.LineComment, merged.FootComment = original.HeadComment, original.LineComment, original.FootComment
+	merged.Anchor = original.Anchor
+	if original.Kind != updated.Kind {
+		return &merged
+	}
+
+	switch updated.Kind {
+	case yaml.MappingNode:
+		merged.Style = original.Style
+		merged.Content = nil
+		for i := 0; i+1 < len(updated.Content); i += 2 {
+			key, value := updated.Content[i], updated.Content[i+1]
+			if originalKey, originalValue := yamlLookup(original, key.Value); originalKey != nil {
+				key, value = originalKey, mergeYAMLNode(originalValue, value)
+			}
+			merged.Content = append(merged.Content, key, value)
+		}
+	case yaml.SequenceNode:
+		merged.Style = original.Style
+		merged.Content = nil
+		for i, item := range updated.Content {
+			if i < len(original.Content) {
+				item = mergeYAMLNode(original.Content[i], item)
+			}
+			merged.Content = append(merged.Content, item)
+		}
+	case yaml.ScalarNode:
+		quoted := yaml.SingleQuotedStyle | yaml.DoubleQuotedStyle | yaml.LiteralStyle | yaml.FoldedStyle
+		if updated.Tag == "!!str" && original.Tag == "!!str" {
+			merged.Style = original.Style & quoted
+		}
+	}
+	return &merged
+}
+
+// yamlLookup returns the key and value nodes of a mapping node, or nil if the key is missing
+func yamlLookup(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// sameYAMLValue reports whether both nodes decode to the same value
+func sameYAMLValue(a, b *yaml.Node) bool {
+	valueA, errA := decodeYAMLNode(a)
+	valueB, errB := decodeYAMLNode(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	jsonA, errA := json.Marshal(valueA)
+	jsonB, errB := json.Marshal(valueB)
+	return errA == nil && errB == nil && bytes.Equal(jsonA, jsonB)
+}
+
+// yamlNode builds the YAML representation of a value returned by decodeOrdered
+func yamlNode(value interface{}) *yaml.Node {
+	switch v := value.(type) {
+	case *tree:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range v.keys {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				yamlNode(v.values[key]))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(string(v), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(v)}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// decodeTOML decodes a TOML document into trees, slices and scalars. The
+// document is checked and decoded by go-toml, the order of the keys is taken
+// from the expressions of the document. Dates and times are kept as strings.
+func decodeTOML(data []byte) (interface{}, error) {
+	order, err := tomlKeyOrder(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var document map[string]interface{}
+	if err := toml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return orderTOML(document, nil, order)
+}
+
+// tomlOrder records the keys of every table in the order they first appear.
+// Elements of arrays share the path of the array.
+type tomlOrder struct {
+	keys map[string][]string // Keys by the path of their table, see tomlOrderKey
+	seen map[string]bool
+}
+
+// tomlKeyOrder returns the order of the keys of the document
+func tomlKeyOrder(data []byte) (map[string][]string, error) {
+	o := &tomlOrder{keys: make(map[string][]string), seen: make(map[string]bool)}
+
+	var current []string
+	p := unstable.Parser{}
+	p.Reset(data)
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.KeyValue:
+			o.noteKeyValue(current, expr)
+		case unstable.Table, unstable.ArrayTable:
+			current = tomlKey(expr)
+			o.note(current)
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, err
+	}
+	return o.keys, nil
+}
+
+// note records every key along path
+func (o *tomlOrder) note(path []string) {
+	for i := range path {
+		if key := tomlOrderKey(path[:i+1]); !o.seen[key] {
+			o.seen[key] = true
+			parent := tomlOrderKey(path[:i])
+			o.keys[parent] = append(o.keys[parent], path[i])
+		}
+	}
+}
+
+// noteKeyValue records the possibly dotted key of a key-value expression in
+// table and the keys of its value
+func (o *tomlOrder) noteKeyValue(table []string, expr *unstable.Node) {
+	path := append(append([]string{}, table...), tomlKey(expr)...)
+	o.note(path)
+	o.noteValue(path, expr.Value())
+}
+
+// noteValue records the keys of inline tables, also inside arrays
+func (o *tomlOrder) noteValue(path []string, node *unstable.Node) {
+	it := node.Children()
+	switch node.Kind {
+	case unstable.InlineTable:
+		for it.Next() {
+			o.noteKeyValue(path, it.Node())
+		}
+	case unstable.Array:
+		for it.Next() {
+			o.noteValue(path, it.Node())
+		}
+	}
+}
+
+// tomlHasComments reports whether the TOML document has any comments
+func tomlHasComments(data []byte) bool {
+	p := unstable.Parser{KeepComments: true}
+	p.Reset(data)
+	for p.NextExpression() {
+		if p.Expression().Kind == unstable.Comment {
+			return true
+		}
+	}
+	return false
+}
+
+// tomlOrderKey identifies a table path in the result of tomlKeyOrder
+func tomlOrderKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// tomlKey returns the parts of the dotted key of a table or key-value expression
+func tomlKey(expr *unstable.Node) []string {
+	var parts []string
+	it := expr.Key()
+	for it.Next() {
+		parts = append(parts, string(it.Node().Data))
+	}
+	return parts
+}
+
+// orderTOML converts a value decoded by go-toml into trees with the keys in the
+// order of the document, slices and scalars
+func orderTOML(value interface{}, path []string, order map[string][]string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := order[tomlOrderKey(path)]
+		var rest []string
+		for key := range v {
+			if !slices.Contains(keys, key) {
+				rest = append(rest, key)
+			}
+		}
+		slices.Sort(rest)
+
+		t := newTree()
+		for _, key := range append(keys, rest...) {
+			child, exists := v[key]
+			if !exists {
+				continue
+			}
+			converted, err := orderTOML(child, append(path, key), order)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.add(key, converted); err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	case []interface{}:
+		list := []interface{}{}
+		for _, item := range v {
+			converted, err := orderTOML(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, converted)
+		}
+		return list, nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("invalid value of '%s': unsupported float %v", strings.Join(path, "."), v)
+		}
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		// Local dates and times
+		return v.String(), nil
+	}
+	return value, nil
+}
+
+// bareTOMLKey matches keys that need no quotes
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// writeTOMLTable writes the key-values of table, then its tables and arrays of
+// tables. The header of a table that only holds further tables is left out.
+func writeTOMLTable(buf *bytes.Buffer, path []string, table *tree) error {
+	var plain, nested []string
+	for _, key := range table.keys {
+		if isTOMLTable(table.values[key]) || isTOMLArrayOfTables(table.values[key]) {
+			nested = append(nested, key)
+		} else {
+			plain = append(plain, key)
+		}
+	}
+
+	if len(path) > 0 && (len(plain) > 0 || len(nested) == 0) {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(buf, "[%s]\n", tomlPath(path))
+	}
+
+	for _, key := range plain {
+		value, err := tomlInline(table.values[key])
+		if err != nil {
+			return fmt.Errorf("cannot write '%s' as TOML: %w", tomlPath(append(path, key)), err)
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlQuoteKey(key), value)
+	}
+
+	for _, key := range nested {
+		childPath := append(append([]string{}, path...), key)
+		switch v := table.values[key].(type) {
+		case *tree:
+			if err := writeTOMLTable(buf, childPath, v); err != nil {
+				return err
+			}
+		case []interface{}:
+			for _, item := range v {
+				if buf.Len() > 0 {
+					buf.WriteByte('\n')
+				}
+				fmt.Fprintf(buf, "[[%s]]\n", tomlPath(childPath))
+				if err := writeTOMLKeyValues(buf, childPath, item.(*tree)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeTOMLKeyValues writes the members of an element of an array of tables inline
+func writeTOMLKeyValues(buf *bytes.Buffer, path []string, table *tree) error {
+	for _, key := range table.keys {
+		value, err := tomlInline(table.values[key])
+		if err != nil {
+			return fmt.Errorf("cannot write '%s' as TOML: %w", tomlPath(append(path, key)), err)
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlQuoteKey(key), value)
+	}
+	return nil
+}
+
+func isTOMLTable(value interface{}) bool {
+	_, ok := value.(*tree)
+	return ok
+}
+
+// isTOMLArrayOfTables reports whether value is a non-empty list of tables only
+func isTOMLArrayOfTables(value interface{}) bool {
+	list, ok := value.([]interface{})
+	if !ok || len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if !isTOMLTable(item) {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlInline formats a value for a key-value line
+func tomlInline(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case *tree:
+		parts := make([]string, 0, len(v.keys))
+		for _, key := range v.keys {
+			inner, err := tomlInline(v.values[key])
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, tomlQuoteKey(key)+" = "+inner)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			inner, err := tomlInline(item)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, inner)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	case json.Number:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return tomlQuote(v), nil
+	}
+	return "", fmt.Errorf("TOML has no null value")
+}
+
+// tomlPath formats a dotted key
+func tomlPath(path []string) string {
+	parts := make([]string, len(path))
+	for i, part := range path {
+		parts[i] = tomlQuoteKey(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func tomlQuoteKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return tomlQuote(key)
+}
+
+// tomlQuote formats a basic string
+func tomlQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFormats(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{
+			name: "yaml",
+			file: ".pair.yaml",
+			data: `coauthors:
+  zoe: {name: Zoe, email: zoe@example.com}
+  adam:
+    name: Adam
+    email: adam@example.com
+    emails: [adam@home.example.org]
+groups:
+  web: [zoe, adam]
+`,
+		},
+		{
+			name: "toml tables",
+			file: ".pair.toml",
+			data: `[coauthors.zoe]
+name = "Zoe"
+email = "zoe@example.com"
+
+[coauthors.adam]
+name = "Adam"
+email = "adam@example.com"
+emails = ["adam@home.example.org"]
+
+[groups]
+web = ["zoe", "adam"]
+`,
+		},
+		{
+			name: "toml inline tables",
+			file: ".pair.toml",
+			data: `groups.web = ["zoe", "adam"]
+
+[coauthors]
+zoe = { name = "Zoe", email = "zoe@example.com" }
+adam = { name = "Adam", email = "adam@example.com", emails = ["adam@home.example.org"] }
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ConfigPath = filepath.Join(t.TempDir(), tc.file)
+			require.NoError(t, os.WriteFile(ConfigPath, []byte(tc.data), 0644))
+
+			require.NoError(t, LoadConfig())
+			require.Len(t, Config.CoAuthors, 2)
+			assert.Equal(t, "zoe", Config.CoAuthors[0].Alias)
+			assert.Equal(t, "adam", Config.CoAuthors[1].Alias)
+			assert.Equal(t, []string{"adam@example.com", "adam@home.example.org"}, Config.CoAuthors[1].AllEmails())
+			assert.Equal(t, []string{"web"}, Config.GroupNames)
+
+			// Saving keeps the format and the order
+			require.NoError(t, AddCoAuthor("bob", models.CoAuthor{Name: "Bob", Email: "bob@example.com"}))
+			require.NoError(t, SaveConfig())
+			require.NoError(t, LoadConfig())
+			var aliases []string
+			for _, author := range Config.CoAuthors {
+				aliases = append(aliases, author.Alias)
+			}
+			assert.Equal(t, []string{"zoe", "adam", "bob"}, aliases)
+		})
+	}
+}
+
+func TestSaveConfigYAML(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), ".pair.yml")
+	data := `# Team roster
+extra: keep me
+coauthors:
+  # Frontend
+  jane: {name: Jane Doe, email: jane@example.com, team: "yes"} # lead
+  john:
+    name: John Doe # nickname JD
+    email: 'john@example.com'
+groups:
+  web: [jane, john] # on call
+`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+	require.NoError(t, LoadConfig())
+	require.NoError(t, UpdateCoAuthor("john", models.CoAuthor{Name: "Johnny Doe", Email: "john@example.com"}))
+	require.NoError(t, AddCoAuthor("sam", models.CoAuthor{Name: "Sam", Email: "sam@example.com"}))
+	require.NoError(t, SaveConfig())
+
+	// Comments and the layout of unchanged entries are kept
+	saved, err := os.ReadFile(ConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, `# Team roster
+version: 1
+extra: keep me
+coauthors:
+  # Frontend
+  jane: {name: Jane Doe, email: jane@example.com, team: "yes"} # lead
+  john:
+    name: Johnny Doe # nickname JD
+    email: 'john@example.com'
+  sam:
+    name: Sam
+    email: sam@example.com
+groups:
+  web: [jane, john] # on call
+`, string(saved))
+}
+
+func TestSaveConfigTOMLRefusesComments(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), ".pair.toml")
+	data := "# Team roster\n[coauthors.jane]\nname = \"Jane Doe\"\nemail = \"jane@example.com\"\n"
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+	require.NoError(t, LoadConfig())
+	require.NoError(t, AddCoAuthor("sam", models.CoAuthor{Name: "Sam", Email: "sam@example.com"}))
+
+	assert.ErrorContains(t, SaveConfig(), "has comments that saving would remove, edit it by hand instead")
+	saved, err := os.ReadFile(ConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(saved))
+}
+
+func TestLoadConfigRejectsInvalidTOML(t *testing.T) {
+	tests := map[string]string{
+		"extended inline table": "[coauthors]\njane = { name = \"Jane\", email = \"jane@example.com\" }\n\n[coauthors.jane.extra]\nteam = \"web\"\n",
+		"duplicate key":         "[coauthors.jane]\nname = \"Jane\"\nname = \"Jane Doe\"\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			ConfigPath = filepath.Join(t.TempDir(), ".pair.toml")
+			require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+			assert.ErrorContains(t, LoadConfig(), "could not parse config file")
+		})
+	}
+}
+
+func TestConvertFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".pair.json")
+	data := `{"coauthors": {"zoe": {"name": "Zoe", "email": "zoe@example.com", "emails": [{"email": "zoe@corp.example.com", "hosts": ["*.corp.example.com"]}]}, "adam": {"name": "Adam \"A\"", "email": "adam@example.com"}}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+
+	converted, err := ConvertFile(path, FormatTOML)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, ".pair.toml"), converted)
+	assert.NoFileExists(t, path)
+
+	saved, err := os.ReadFile(converted)
+	require.NoError(t, err)
+	assert.Equal(t, `[coauthors.zoe]
+name = "Zoe"
+email = "zoe@example.com"
+
+[[coauthors.zoe.emails]]
+email = "zoe@corp.example.com"
+hosts = ["*.corp.example.com"]
+
+[coauthors.adam]
+name = "Adam \"A\""
+email = "adam@example.com"
+`, string(saved))
+
+	info, err := os.Stat(converted)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// And back, through YAML
+	converted, err = ConvertFile(converted, FormatYAML)
+	require.NoError(t, err)
+	converted, err = ConvertFile(converted, FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, path, converted)
+
+	ConfigPath = converted
+	require.NoError(t, LoadConfig())
+	assert.Equal(t, "zoe", Config.CoAuthors[0].Alias)
+	assert.Equal(t, []string{"*.corp.example.com"}, Config.CoAuthors[0].Emails[0].Hosts)
+
+	_, err = ConvertFile(converted, FormatJSON)
+	assert.ErrorContains(t, err, "is already a JSON file")
+	_, err = ConvertFile(converted, "ini")
+	assert.ErrorContains(t, err, "unsupported roster format 'ini'")
+}
+
+func TestConvertFileRefusesNullInTOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".pair.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"coauthors": {"jane": null}}`), 0644))
+
+	_, err := ConvertFile(path, FormatTOML)
+	assert.ErrorContains(t, err, "cannot write 'coauthors.jane' as TOML: TOML has no null value")
+	assert.FileExists(t, path)
+}
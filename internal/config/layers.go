@@ -0,0 +1,161 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+)
+
+// Layers of the roster, from lowest to highest precedence
+const (
+	LayerSystem     = "system"
+	LayerUser       = "user"
+	LayerRepository = "repository"
+	LayerFile       = "file" // A single file given with --config
+)
+
+// rosterFileName is the name of user and repository roster files without extension
+const rosterFileName = ".pair"
+
+// SystemConfigPath is the roster shared by all users of the machine. Like the
+// other roster files it can also be YAML or TOML.
+var SystemConfigPath = defaultSystemConfigPath()
+
+// Source is a roster file that was loaded, with what it contributed
+type Source struct {
+	Path      string
+	Layer     string
+	CoAuthors []string // Aliases defined in the file
+	Groups    []string // Groups defined in the file
+	Removed   []string // Aliases and groups set to null, hiding them in lower layers
+}
+
+// Sources lists the files the roster was loaded from, lowest precedence first
+var Sources []Source
+
+// sourcePath is a candidate roster file
+type sourcePath struct {
+	path  string
+	layer string
+}
+
+func defaultSystemConfigPath() string {
+	if runtime.GOOS == "windows" {
+		return rosterFileIn(filepath.Join(os.Getenv("ProgramData"), "pair"), "pair")
+	}
+	return rosterFileIn("/etc/pair", "pair")
+}
+
+// rosterFileIn returns the roster file named name in dir with the first
+// extension that exists, see formatExtensions, or the JSON file if none exists
+func rosterFileIn(dir, name string) string {
+	for _, candidate := range formatExtensions {
+		path := filepath.Join(dir, name+candidate.ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, name+".json")
+}
+
+// userConfigPath returns the roster in the home directory
+func userConfigPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		// If we can't get home dir, use current directory as fallback
+		return rosterFileIn(".", rosterFileName)
+	}
+	return rosterFileIn(homeDir, rosterFileName)
+}
+
+// sourcePaths returns the candidate roster files, lowest precedence first: the
+// system file, the user file and the .pair.json (or .yaml, .yml, .toml) of every
+// directory from the repository root down to the current directory. Outside of a repository only
+// the current directory is searched.
+func sourcePaths() []sourcePath {
+	paths := []sourcePath{
+		{path: SystemConfigPath, layer: LayerSystem},
+		{path: userConfigPath(), layer: LayerUser},
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return paths
+	}
+	cwd = resolvePath(cwd)
+
+	dirs := []string{cwd}
+	if top := git.TopLevel(); top != "" {
+		top = resolvePath(top)
+		if rel, err := filepath.Rel(top, cwd); err == nil && !strings.HasPrefix(rel, "..") {
+			dirs = nil
+			for dir := cwd; ; dir = filepath.Dir(dir) {
+				dirs = append([]string{dir}, dirs...)
+				if dir == top || filepath.Dir(dir) == dir {
+					break
+				}
+			}
+		}
+	}
+
+	seen := map[string]bool{resolvePath(paths[0].path): true, resolvePath(paths[1].path): true}
+	for _, dir := range dirs {
+		path := rosterFileIn(dir, rosterFileName)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, sourcePath{path: path, layer: LayerRepository})
+	}
+	return paths
+}
+
+// resolvePath makes path absolute and resolves symbolic links of existing parts,
+// so that the same directory is recognized under different names
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	if resolvedDir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil {
+		return filepath.Join(resolvedDir, filepath.Base(path))
+	}
+	return path
+}
+
+// loadLayers merges all existing roster files. The result cannot be saved.
+func loadLayers() error {
+	loaded.path, loaded.data, loaded.exists, loaded.layered = "", nil, false, true
+	loaded.document, loaded.entries, loaded.merged = nil, make(map[string]*object), models.Config{}
+	loaded.otherGroups, loaded.otherAliases = nil, nil
+	Sources = nil
+
+	var layers []*layer
+	var layerNames []string
+	for _, candidate := range sourcePaths() {
+		l, err := parseLayer(candidate.path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return err
+		}
+		layers = append(layers, l)
+		layerNames = append(layerNames, candidate.layer)
+	}
+
+	if len(layers) == 0 {
+		return fmt.Errorf("could not read config file: no roster found, create one with 'pair init': %w", fs.ErrNotExist)
+	}
+
+	return merge(layers, layerNames, nil)
+}
@@ -0,0 +1,218 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupLayers creates a home directory, a system roster path and a repository
+// with a subdirectory that becomes the current directory. It returns the
+// repository root and the subdirectory.
+func setupLayers(t *testing.T) (string, string) {
+	base := resolvePath(t.TempDir())
+	home := filepath.Join(base, "home")
+	repo := filepath.Join(base, "repo")
+	sub := filepath.Join(repo, "service")
+	require.NoError(t, os.MkdirAll(home, 0755))
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	t.Setenv("HOME", home)
+	ConfigPath = ""
+	previousSystem := SystemConfigPath
+	SystemConfigPath = filepath.Join(base, "system.json")
+
+	previousGit := git.Default
+	fake := git.NewFake()
+	fake.GitDir = filepath.Join(repo, ".git")
+	git.Default = fake
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(sub))
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+		git.Default = previousGit
+		SystemConfigPath = previousSystem
+	})
+
+	return repo, sub
+}
+
+func writeRoster(t *testing.T, path, data string) {
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+}
+
+func TestLoadConfigMergesLayers(t *testing.T) {
+	repo, sub := setupLayers(t)
+	home := os.Getenv("HOME")
+
+	writeRoster(t, SystemConfigPath, `{"coauthors": {
+		"jane": {"name": "Jane Doe", "email": "jane@example.com"},
+		"bot": {"name": "Build Bot", "email": "bot@example.com"}
+	}}`)
+	writeRoster(t, filepath.Join(home, ".pair.json"), `{"coauthors": {
+		"john": {"name": "John Doe", "email": "john@example.com"}
+	}, "groups": {"duo": ["jane", "john"]}}`)
+	writeRoster(t, filepath.Join(repo, ".pair.json"), `{"coauthors": {
+		"jane": {"name": "Jane Work", "email": "jane@work.example.com"},
+		"bot": null
+	}}`)
+	writeRoster(t, filepath.Join(sub, ".pair.json"), `{"coauthors": {
+		"sam": {"name": "Sam", "email": "sam@example.com"}
+	}, "groups": {"service": ["sam", "jane"]}}`)
+
+	require.NoError(t, LoadConfig())
+
+	// Overridden entries keep their position, removed ones disappear
+	var aliases []string
+	for _, author := range Config.CoAuthors {
+		aliases = append(aliases, author.Alias)
+	}
+	assert.Equal(t, []string{"jane", "john", "sam"}, aliases)
+	assert.Equal(t, "jane@work.example.com", Config.CoAuthorsMap["jane"].Email)
+	assert.Equal(t, []string{"duo", "service"}, Config.GroupNames)
+
+	require.Len(t, Sources, 4)
+	assert.Equal(t, LayerSystem, Sources[0].Layer)
+	assert.Equal(t, LayerUser, Sources[1].Layer)
+	assert.Equal(t, Source{
+		Path:      filepath.Join(repo, ".pair.json"),
+		Layer:     LayerRepository,
+		CoAuthors: []string{"jane"},
+		Removed:   []string{"bot"},
+	}, Sources[2])
+	assert.Equal(t, filepath.Join(sub, ".pair.json"), Sources[3].Path)
+
+	// The merged roster cannot be saved, changes go to the nearest file
+	assert.ErrorContains(t, SaveConfig(), "merged from several files")
+	assert.Equal(t, filepath.Join(sub, ".pair.json"), GetConfigPath())
+
+	require.NoError(t, LoadConfigForWrite())
+	require.NoError(t, AddCoAuthor("ana", models.CoAuthor{Name: "Ana", Email: "ana@example.com"}))
+	require.NoError(t, SaveConfig())
+
+	require.NoError(t, LoadConfig())
+	assert.Contains(t, Config.CoAuthorsMap, "ana")
+	assert.Contains(t, Config.CoAuthorsMap, "john")
+
+	// Aliases of the other files cannot be taken, the merged roster would be invalid
+	require.NoError(t, LoadConfigForWrite())
+	assert.ErrorContains(t, AddCoAuthor("duo", models.CoAuthor{Name: "Duo", Email: "duo@example.com"}), "alias 'duo' is already used by a group")
+	assert.ErrorContains(t, AddCoAuthor("john", models.CoAuthor{Name: "Johnny", Email: "johnny@example.com"}), "alias 'john' is already used by John Doe")
+	assert.ErrorContains(t, RenameCoAuthor("ana", "duo"), "alias 'duo' is already used by a group")
+	require.NoError(t, LoadConfig())
+}
+
+func TestLoadConfigValidatesMergedGroups(t *testing.T) {
+	repo, _ := setupLayers(t)
+	home := os.Getenv("HOME")
+
+	writeRoster(t, filepath.Join(home, ".pair.json"), `{"coauthors": {
+		"jane": {"name": "Jane Doe", "email": "jane@example.com"}
+	}, "groups": {"squad": ["jane"]}}`)
+	writeRoster(t, filepath.Join(repo, ".pair.json"), `{"coauthors": {"jane": null}}`)
+
+	assert.ErrorContains(t, LoadConfig(), "invalid group 'squad': no co-author found with alias 'jane'")
+}
+
+func TestSaveConfigKeepsRemovals(t *testing.T) {
+	repo, _ := setupLayers(t)
+	home := os.Getenv("HOME")
+
+	writeRoster(t, filepath.Join(home, ".pair.json"), `{"coauthors": {
+		"john": {"name": "John Doe", "email": "john@example.com"}
+	}, "groups": {"old": ["john"]}}`)
+	path := filepath.Join(repo, ".pair.json")
+	writeRoster(t, path, `{"coauthors": {
+		"jane": {"name": "Jane Doe", "email": "jane@example.com"},
+		"john": null,
+		"sam": {"name": "Sam Smith", "email": "sam@example.com"}
+	}, "groups": {"old": null}}`)
+
+	require.NoError(t, LoadConfigForWrite())
+	require.NoError(t, AddCoAuthor("bob", models.CoAuthor{Name: "Bob", Email: "bob@example.com"}))
+	require.NoError(t, SaveConfig())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "version": 1,
+  "coauthors": {
+    "jane": {
+      "name": "Jane Doe",
+      "email": "jane@example.com"
+    },
+    "john": null,
+    "sam": {
+      "name": "Sam Smith",
+      "email": "sam@example.com"
+    },
+    "bob": {
+      "name": "Bob",
+      "email": "bob@example.com"
+    }
+  },
+  "groups": {
+    "old": null
+  }
+}
+`, string(data))
+
+	require.NoError(t, LoadConfig())
+	assert.NotContains(t, Config.CoAuthorsMap, "john")
+	assert.NotContains(t, Config.Groups, "old")
+}
+
+func TestChangesRespectOtherLayers(t *testing.T) {
+	repo, _ := setupLayers(t)
+	home := os.Getenv("HOME")
+
+	writeRoster(t, filepath.Join(home, ".pair.json"), `{"coauthors": {
+		"john": {"name": "John Doe", "email": "john@example.com"}
+	}, "groups": {"squad": ["jane", "john"]}}`)
+	writeRoster(t, filepath.Join(repo, ".pair.json"), `{"coauthors": {
+		"jane": {"name": "Jane Doe", "email": "jane@example.com"},
+		"sam": {"name": "Sam Smith", "email": "sam@example.com"}
+	}}`)
+
+	require.NoError(t, LoadConfigForWrite())
+
+	// Emails of the other files are taken
+	assert.EqualError(t, AddCoAuthor("johnny", models.CoAuthor{Name: "Johnny", Email: "john@example.com"}),
+		"email 'john@example.com' is already used by 'john'")
+	assert.EqualError(t, UpdateCoAuthor("sam", models.CoAuthor{Name: "Sam Smith", Email: "JOHN@example.com"}),
+		"email 'JOHN@example.com' is already used by 'john'")
+
+	// Members of groups in the other files can be neither renamed nor deleted
+	assert.ErrorContains(t, RenameCoAuthor("jane", "janet"), "alias 'jane' is a member of group 'squad' in "+filepath.Join(home, ".pair.json"))
+	_, err := DeleteCoAuthor("jane")
+	assert.ErrorContains(t, err, "alias 'jane' is a member of group 'squad'")
+
+	require.NoError(t, RenameCoAuthor("sam", "samuel"))
+	_, err = DeleteCoAuthor("samuel")
+	require.NoError(t, err)
+}
+
+func TestLoadConfigWithoutRoster(t *testing.T) {
+	_, _ = setupLayers(t)
+
+	assert.ErrorIs(t, LoadConfig(), os.ErrNotExist)
+	assert.Equal(t, filepath.Join(os.Getenv("HOME"), ".pair.json"), GetConfigPath())
+
+	// Adding the first co-author creates the roster
+	require.NoError(t, LoadConfigForWrite())
+	require.NoError(t, AddCoAuthor("jane", models.CoAuthor{Name: "Jane Doe", Email: "jane@example.com"}))
+	require.NoError(t, SaveConfig())
+
+	require.NoError(t, LoadConfig())
+	assert.Equal(t, "Jane Doe", Config.CoAuthorsMap["jane"].Name)
+	data, err := os.ReadFile(GetConfigPath())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"version"`)
+}
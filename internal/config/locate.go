@@ -0,0 +1,245 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+)
+
+// position is a line and column in a roster file, both starting at 1
+type position struct {
+	line   int
+	column int
+}
+
+// keyPositions maps the dotted path of every key and array element of a roster
+// file, e.g. "coauthors.jane.email" or "groups.squad.0", to where it appears.
+// A key that appears more than once has several positions.
+type keyPositions map[string][]position
+
+func (k keyPositions) add(path []string, pos position) {
+	key := strings.Join(path, ".")
+	k[key] = append(k[key], pos)
+}
+
+// find returns the position of the nth occurrence of path. If the key is not in
+// the file, e.g. because a required field is missing, the closest parent is used.
+func (k keyPositions) find(path []string, nth int) (position, bool) {
+	for i := len(path); i > 0; i-- {
+		positions := k[strings.Join(path[:i], ".")]
+		if len(positions) == 0 {
+			continue
+		}
+		if i < len(path) || nth >= len(positions) {
+			nth = 0
+		}
+		return positions[nth], true
+	}
+	return position{}, false
+}
+
+// locate finds the positions of all keys of a roster file. Files that do not
+// parse yield the positions found up to the error.
+func locate(data []byte, format string) keyPositions {
+	positions := keyPositions{}
+	switch format {
+	case FormatYAML:
+		locateYAML(data, positions)
+	case FormatTOML:
+		locateTOML(data, positions)
+	default:
+		locateJSON(data, positions)
+	}
+	return positions
+}
+
+// locateJSON walks the tokens of a JSON document
+func locateJSON(data []byte, positions keyPositions) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// start returns the position of the next token after the offset
+	start := func(offset int64) position {
+		for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+			offset++
+		}
+		return offsetPosition(data, offset)
+	}
+
+	var walk func(path []string) error
+	walk = func(path []string) error {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'):
+			for dec.More() {
+				pos := start(dec.InputOffset())
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := append(path[:len(path):len(path)], key.(string))
+				positions.add(child, pos)
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				child := append(path[:len(path):len(path)], strconv.Itoa(i))
+				positions.add(child, start(dec.InputOffset()))
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+		default:
+			return nil
+		}
+
+		// The closing delimiter
+		_, err = dec.Token()
+		return err
+	}
+
+	_ = walk(nil)
+}
+
+// locateYAML walks the nodes of a YAML document
+func locateYAML(data []byte, positions keyPositions) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return
+	}
+
+	var walk func(node *yaml.Node, path []string)
+	walk = func(node *yaml.Node, path []string) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				child := append(path[:len(path):len(path)], key.Value)
+				positions.add(child, position{key.Line, key.Column})
+				walk(node.Content[i+1], child)
+			}
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				child := append(path[:len(path):len(path)], strconv.Itoa(i))
+				positions.add(child, position{item.Line, item.Column})
+				walk(item, child)
+			}
+		}
+	}
+
+	walk(doc.Content[0], nil)
+}
+
+// locateTOML walks the expressions of a TOML document
+func locateTOML(data []byte, positions keyPositions) {
+	p := unstable.Parser{}
+	p.Reset(data)
+
+	at := func(node *unstable.Node) position {
+		start := p.Shape(node.Raw).Start
+		return position{start.Line, start.Column}
+	}
+
+	// keyPath adds the positions of the parts of a dotted key below path
+	keyPath := func(expr *unstable.Node, path []string) []string {
+		it := expr.Key()
+		for it.Next() {
+			path = append(path[:len(path):len(path)], string(it.Node().Data))
+			if it.Node().Raw.Length > 0 {
+				positions.add(path, at(it.Node()))
+			}
+		}
+		return path
+	}
+
+	var value func(node *unstable.Node, path []string)
+	value = func(node *unstable.Node, path []string) {
+		switch node.Kind {
+		case unstable.Array:
+			it := node.Children()
+			for i := 0; it.Next(); i++ {
+				child := append(path[:len(path):len(path)], strconv.Itoa(i))
+				if it.Node().Raw.Length > 0 {
+					positions.add(child, at(it.Node()))
+				}
+				value(it.Node(), child)
+			}
+		case unstable.InlineTable:
+			it := node.Children()
+			for it.Next() {
+				if it.Node().Kind == unstable.KeyValue {
+					value(it.Node().Value(), keyPath(it.Node(), path))
+				}
+			}
+		}
+	}
+
+	var table []string
+	arrays := map[string]int{} // Number of tables of every array of tables so far
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.KeyValue:
+			value(expr.Value(), keyPath(expr, table))
+		case unstable.Table:
+			table = keyPath(expr, nil)
+		case unstable.ArrayTable:
+			table = keyPath(expr, nil)
+			key := strings.Join(table, ".")
+			table = append(table, strconv.Itoa(arrays[key]))
+			arrays[key]++
+		}
+	}
+}
+
+// yamlLine finds the line in the messages of yaml.v3 errors
+var yamlLine = regexp.MustCompile(`line (\d+)`)
+
+// errorPosition returns where a syntax error in a roster file was found
+func errorPosition(data []byte, format string, err error) (position, bool) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var tomlErr *unstable.ParserError
+
+	switch {
+	case format == FormatJSON && errors.As(err, &syntaxErr):
+		// The offset is just past the offending character
+		return offsetPosition(data, max(syntaxErr.Offset-1, 0)), true
+	case format == FormatJSON && errors.As(err, &typeErr):
+		return offsetPosition(data, typeErr.Offset), true
+	case format == FormatTOML && errors.As(err, &tomlErr):
+		// The highlighted bytes point into data
+		if offset := cap(data) - cap(tomlErr.Highlight); tomlErr.Highlight != nil && offset >= 0 && offset <= len(data) {
+			return offsetPosition(data, int64(offset)), true
+		}
+	case format == FormatYAML:
+		if match := yamlLine.FindStringSubmatch(err.Error()); match != nil {
+			line, _ := strconv.Atoi(match[1])
+			return position{line: line}, true
+		}
+	}
+	return position{}, false
+}
+
+// offsetPosition converts a byte offset into a line and column
+func offsetPosition(data []byte, offset int64) position {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	lead := data[:offset]
+	return position{
+		line:   bytes.Count(lead, []byte{'\n'}) + 1,
+		column: len(lead) - bytes.LastIndexByte(lead, '\n'),
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package config
+
+// LockDir is a no-op where advisory locks are not available. Saves are still
+// atomic and refuse to overwrite changes made since the roster was loaded.
+func LockDir(path string) (func(), error) {
+	return func() {}, nil
+}
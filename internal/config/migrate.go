@@ -0,0 +1,151 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// CurrentVersion is the version of the roster format written by this pair.
+// Rosters without a version key are version 0.
+const CurrentVersion = 1
+
+// SchemaURL is the JSON Schema of the current roster format
+const SchemaURL = "https://philippeckel.github.io/pair/pair.schema.json"
+
+// migration upgrades a roster document from version from to from+1
+type migration struct {
+	from  int
+	apply func(doc *tree) error
+}
+
+// migrations are applied in order to rosters older than CurrentVersion
+var migrations = []migration{
+	{from: 0, apply: migrateCoAuthorList},
+}
+
+// Migration describes how upgrading a roster file to CurrentVersion changes it
+type Migration struct {
+	Path   string
+	From   int    // Version of the file
+	To     int    // Version after migrating
+	Before []byte // Contents of the file
+	After  []byte // Contents after migrating
+}
+
+// PlanMigration loads the roster file changes are written to, see
+// GetConfigPath, and returns how migrating it would change it. SaveConfig
+// writes the migrated file.
+func PlanMigration() (*Migration, error) {
+	if err := LoadConfigForWrite(); err != nil {
+		return nil, err
+	}
+
+	after, err := encodeFile(loaded.path)
+	if err != nil {
+		return nil, err
+	}
+	return &Migration{
+		Path:   loaded.path,
+		From:   loaded.version,
+		To:     CurrentVersion,
+		Before: loaded.data,
+		After:  after,
+	}, nil
+}
+
+// migrate upgrades a JSON roster to CurrentVersion, keeping the order of all
+// keys. It returns the migrated roster and the version it had. Current rosters
+// are returned unchanged.
+func migrate(data []byte) ([]byte, int, error) {
+	value, err := decodeOrdered(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var doc *tree
+	switch v := value.(type) {
+	case *tree:
+		doc = v
+	case []interface{}:
+		// The oldest rosters were a bare list of co-authors
+		doc = newTree()
+		_ = doc.add("coauthors", v)
+	default:
+		return nil, 0, fmt.Errorf("the roster must be an object")
+	}
+
+	version, err := documentVersion(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version > CurrentVersion {
+		return nil, 0, fmt.Errorf("roster version %d is newer than this pair supports (%d), please upgrade pair", version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return data, version, nil
+	}
+
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		if err := m.apply(doc); err != nil {
+			return nil, 0, fmt.Errorf("could not migrate roster from version %d: %w", m.from, err)
+		}
+	}
+	doc.setFirst("version", json.Number(strconv.Itoa(CurrentVersion)))
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	return migrated, version, nil
+}
+
+// documentVersion returns the version key of the roster, 0 if it is missing
+func documentVersion(doc *tree) (int, error) {
+	value, exists := doc.values["version"]
+	if !exists {
+		return 0, nil
+	}
+
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("invalid roster version %v, must be a number", value)
+	}
+	version, err := strconv.Atoi(string(number))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid roster version %s", number)
+	}
+	return version, nil
+}
+
+// migrateCoAuthorList turns the legacy list of co-authors, each with an alias
+// field, into the map from alias to co-author
+func migrateCoAuthorList(doc *tree) error {
+	list, ok := doc.values["coauthors"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	coAuthors := newTree()
+	for i, item := range list {
+		entry, ok := item.(*tree)
+		if !ok {
+			return fmt.Errorf("co-author %d is not an object", i)
+		}
+		alias, ok := entry.values["alias"].(string)
+		if !ok || alias == "" {
+			return fmt.Errorf("co-author %d has no alias", i)
+		}
+		entry.remove("alias")
+
+		if err := coAuthors.add(alias, entry); err != nil {
+			return fmt.Errorf("co-author %d: %w", i, err)
+		}
+	}
+
+	doc.values["coauthors"] = coAuthors
+	return nil
+}
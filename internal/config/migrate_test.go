@@ -0,0 +1,115 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		expect         string
+		expectVersion  int
+		expectErrMatch string
+	}{
+		{
+			name:          "current roster is unchanged",
+			data:          `{"version": 1, "coauthors": {}}`,
+			expect:        `{"version": 1, "coauthors": {}}`,
+			expectVersion: 1,
+		},
+		{
+			name:          "unversioned map gets a version",
+			data:          `{"coauthors": {"jane": {"name": "Jane", "email": "jane@example.com"}}}`,
+			expect:        `{"version":1,"coauthors":{"jane":{"name":"Jane","email":"jane@example.com"}}}`,
+			expectVersion: 0,
+		},
+		{
+			name:          "legacy list keeps order and unknown fields",
+			data:          `{"coauthors": [{"alias": "zoe", "name": "Zoe", "email": "zoe@example.com", "team": "web"}, {"name": "Adam", "email": "adam@example.com", "alias": "adam"}]}`,
+			expect:        `{"version":1,"coauthors":{"zoe":{"name":"Zoe","email":"zoe@example.com","team":"web"},"adam":{"name":"Adam","email":"adam@example.com"}}}`,
+			expectVersion: 0,
+		},
+		{
+			name:          "bare legacy list",
+			data:          `[{"alias": "jane", "name": "Jane", "email": "jane@example.com"}]`,
+			expect:        `{"version":1,"coauthors":{"jane":{"name":"Jane","email":"jane@example.com"}}}`,
+			expectVersion: 0,
+		},
+		{
+			name:           "legacy entry without alias",
+			data:           `{"coauthors": [{"name": "Jane", "email": "jane@example.com"}]}`,
+			expectErrMatch: "co-author 0 has no alias",
+		},
+		{
+			name:           "duplicate legacy alias",
+			data:           `[{"alias": "jane", "name": "Jane", "email": "jane@example.com"}, {"alias": "jane", "name": "Jane", "email": "jane@example.org"}]`,
+			expectErrMatch: "co-author 1: duplicate key 'jane'",
+		},
+		{
+			name:           "newer roster",
+			data:           `{"version": 2}`,
+			expectErrMatch: "roster version 2 is newer than this pair supports (1)",
+		},
+		{
+			name:           "invalid version",
+			data:           `{"version": "one"}`,
+			expectErrMatch: "invalid roster version one, must be a number",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			migrated, version, err := migrate([]byte(tc.data))
+			if tc.expectErrMatch != "" {
+				assert.ErrorContains(t, err, tc.expectErrMatch)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, string(migrated))
+			assert.Equal(t, tc.expectVersion, version)
+		})
+	}
+}
+
+func TestPlanMigration(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), ".pair.yaml")
+	data := `coauthors:
+  - alias: jane
+    name: Jane Doe
+    email: jane@example.com
+`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+
+	plan, err := PlanMigration()
+	require.NoError(t, err)
+	assert.Equal(t, 0, plan.From)
+	assert.Equal(t, CurrentVersion, plan.To)
+	assert.Equal(t, data, string(plan.Before))
+	assert.Equal(t, `version: 1
+coauthors:
+  jane:
+    name: Jane Doe
+    email: jane@example.com
+`, string(plan.After))
+
+	// Planning does not write, saving writes the planned file
+	saved, err := os.ReadFile(ConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, data, string(saved))
+
+	require.NoError(t, SaveConfig())
+	saved, err = os.ReadFile(ConfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(plan.After), string(saved))
+
+	plan, err = PlanMigration()
+	require.NoError(t, err)
+	assert.Equal(t, CurrentVersion, plan.From)
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/models"
+)
+
+// ValidateAlias checks that alias can be used for a new roster entry or group.
+// Loaded for writing, the aliases of the other roster files are taken as well,
+// as the merged roster could not be loaded otherwise.
+func ValidateAlias(alias string) error {
+	if err := validateAliasName(alias); err != nil {
+		return err
+	}
+	for _, roster := range []models.Config{Config, loaded.merged} {
+		if author, exists := roster.CoAuthorsMap[alias]; exists {
+			return fmt.Errorf("alias '%s' is already used by %s", alias, author.Name)
+		}
+		if _, exists := roster.Groups[alias]; exists {
+			return fmt.Errorf("alias '%s' is already used by a group", alias)
+		}
+	}
+	return nil
+}
+
+// validateAliasName checks that alias can be told apart from other arguments
+func validateAliasName(alias string) error {
+	if alias == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+	if strings.ContainsAny(alias, " \t\n") {
+		return fmt.Errorf("alias '%s' must not contain whitespace", alias)
+	}
+	if _, err := strconv.Atoi(alias); err == nil {
+		// Numbers select co-authors by index
+		return fmt.Errorf("alias '%s' must not be a number", alias)
+	}
+	return nil
+}
+
+// AddCoAuthor adds a co-author to the end of the roster
+func AddCoAuthor(alias string, author models.CoAuthor) error {
+	if err := ValidateAlias(alias); err != nil {
+		return err
+	}
+
+	author.Alias = alias
+	if err := author.Validate(); err != nil {
+		return fmt.Errorf("invalid co-author '%s': %w", alias, err)
+	}
+	if err := checkEmailsUnused(author); err != nil {
+		return err
+	}
+
+	if Config.CoAuthorsMap == nil {
+		Config.CoAuthorsMap = make(map[string]models.CoAuthor)
+	}
+	Config.CoAuthorsMap[alias] = author
+	Config.CoAuthors = append(Config.CoAuthors, author)
+	return nil
+}
+
+// UpdateCoAuthor replaces the details of an existing co-author, keeping its position
+func UpdateCoAuthor(alias string, author models.CoAuthor) error {
+	index, err := rosterIndex(alias)
+	if err != nil {
+		return err
+	}
+
+	author.Alias = alias
+	if err := author.Validate(); err != nil {
+		return fmt.Errorf("invalid co-author '%s': %w", alias, err)
+	}
+	if err := checkEmailsUnused(author); err != nil {
+		return err
+	}
+
+	Config.CoAuthorsMap[alias] = author
+	Config.CoAuthors[index] = author
+	return nil
+}
+
+// checkEmailsUnused fails if another co-author of the roster uses one of the
+// emails of author. Loaded for writing, the co-authors of the other roster
+// files count as well, only one of them could get the credit.
+func checkEmailsUnused(author models.CoAuthor) error {
+	for _, roster := range []models.Config{Config, loaded.merged} {
+		for _, other := range roster.CoAuthors {
+			if other.Alias == author.Alias {
+				continue
+			}
+			for _, email := range author.AllEmails() {
+				for _, otherEmail := range other.AllEmails() {
+					if strings.EqualFold(email, otherEmail) {
+						return fmt.Errorf("email '%s' is already used by '%s'", email, other.Alias)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkUnreferenced fails if a group of another roster file has alias as
+// member and no other file defines it, as the merged roster could no longer be
+// loaded once alias is renamed or deleted in this one
+func checkUnreferenced(alias string) error {
+	if loaded.otherAliases[alias] {
+		return nil
+	}
+	for _, name := range loaded.merged.GroupNames {
+		path, ok := loaded.otherGroups[name]
+		if !ok {
+			continue
+		}
+		for _, member := range loaded.merged.Groups[name] {
+			if member == alias {
+				return fmt.Errorf("alias '%s' is a member of group '%s' in %s, remove it from the group there first", alias, name, path)
+			}
+		}
+	}
+	return nil
+}
+
+// RenameCoAuthor changes the alias of a co-author, including its group memberships.
+// Groups of other roster files are not changed, an alias they refer to is refused.
+func RenameCoAuthor(oldAlias, newAlias string) error {
+	index, err := rosterIndex(oldAlias)
+	if err != nil {
+		return err
+	}
+	if err := ValidateAlias(newAlias); err != nil {
+		return err
+	}
+	if err := checkUnreferenced(oldAlias); err != nil {
+		return err
+	}
+
+	author := Config.CoAuthorsMap[oldAlias]
+	author.Alias = newAlias
+	delete(Config.CoAuthorsMap, oldAlias)
+	Config.CoAuthorsMap[newAlias] = author
+	Config.CoAuthors[index] = author
+
+	// Unknown fields of the entry move along
+	if entry, ok := loaded.entries[oldAlias]; ok {
+		delete(loaded.entries, oldAlias)
+		loaded.entries[newAlias] = entry
+	}
+
+	for name, members := range Config.Groups {
+		for i, member := range members {
+			if member == oldAlias {
+				Config.Groups[name][i] = newAlias
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteCoAuthor removes a co-author from the roster and from all groups.
+// Groups left without members are deleted, their names are returned. Groups of
+// other roster files are not changed, an alias they refer to is refused.
+func DeleteCoAuthor(alias string) ([]string, error) {
+	index, err := rosterIndex(alias)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnreferenced(alias); err != nil {
+		return nil, err
+	}
+
+	delete(Config.CoAuthorsMap, alias)
+	Config.CoAuthors = append(Config.CoAuthors[:index], Config.CoAuthors[index+1:]...)
+
+	var deletedGroups []string
+	var groupNames []string
+	for _, name := range Config.GroupNames {
+		var members []string
+		for _, member := range Config.Groups[name] {
+			if member != alias {
+				members = append(members, member)
+			}
+		}
+
+		if len(members) == 0 {
+			delete(Config.Groups, name)
+			deletedGroups = append(deletedGroups, name)
+			continue
+		}
+		Config.Groups[name] = members
+		groupNames = append(groupNames, name)
+	}
+	Config.GroupNames = groupNames
+
+	return deletedGroups, nil
+}
+
+// rosterIndex returns the position of alias in the roster
+func rosterIndex(alias string) (int, error) {
+	for i, author := range Config.CoAuthors {
+		if author.Alias == alias {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no co-author found with alias '%s'", alias)
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadRoster writes and loads a roster with two co-authors and two groups
+func loadRoster(t *testing.T) {
+	t.Helper()
+	ConfigPath = filepath.Join(t.TempDir(), ".pair.json")
+	data := `{
+  "coauthors": {
+    "jane": {"name": "Jane Doe", "email": "jane@example.com"},
+    "john": {"name": "John Doe", "email": "john@example.com"}
+  },
+  "groups": {"duo": ["jane", "john"], "solo": ["john"]}
+}`
+	require.NoError(t, os.WriteFile(ConfigPath, []byte(data), 0644))
+	require.NoError(t, LoadConfig())
+}
+
+func TestAddCoAuthor(t *testing.T) {
+	loadRoster(t)
+
+	require.NoError(t, AddCoAuthor("sam", models.CoAuthor{Name: "Sam Smith", Email: "sam@example.com"}))
+	assert.Equal(t, "sam", Config.CoAuthors[2].Alias)
+
+	tests := map[string]string{
+		"jane": "alias 'jane' is already used by Jane Doe",
+		"duo":  "alias 'duo' is already used by a group",
+		"3":    "alias '3' must not be a number",
+		"a b":  "must not contain whitespace",
+		"":     "alias cannot be empty",
+	}
+	for alias, expectErr := range tests {
+		assert.ErrorContains(t, AddCoAuthor(alias, models.CoAuthor{Name: "X", Email: "x@example.com"}), expectErr)
+	}
+	assert.ErrorContains(t, AddCoAuthor("kim", models.CoAuthor{Name: "Kim", Email: "kim"}), "email must contain '@' character")
+	assert.ErrorContains(t, AddCoAuthor("jd", models.CoAuthor{Name: "Jane", Email: "JANE@example.com"}), "email 'JANE@example.com' is already used by 'jane'")
+	assert.ErrorContains(t, AddCoAuthor("jd", models.CoAuthor{Name: "Jane\nDoe", Email: "jd@example.com"}), `use "Jane Doe"`)
+}
+
+func TestRenameCoAuthor(t *testing.T) {
+	loadRoster(t)
+
+	require.NoError(t, RenameCoAuthor("john", "jd"))
+	require.NoError(t, SaveConfig())
+	require.NoError(t, LoadConfig())
+
+	assert.Equal(t, "John Doe", Config.CoAuthorsMap["jd"].Name)
+	assert.NotContains(t, Config.CoAuthorsMap, "john")
+	assert.Equal(t, []string{"jane", "jd"}, Config.Groups["duo"])
+	assert.Equal(t, []string{"jd"}, Config.Groups["solo"])
+
+	assert.ErrorContains(t, RenameCoAuthor("nobody", "x"), "no co-author found with alias 'nobody'")
+	assert.ErrorContains(t, RenameCoAuthor("jane", "jd"), "already used")
+}
+
+func TestDeleteCoAuthor(t *testing.T) {
+	loadRoster(t)
+
+	deletedGroups, err := DeleteCoAuthor("john")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"solo"}, deletedGroups)
+
+	require.NoError(t, SaveConfig())
+	require.NoError(t, LoadConfig())
+	assert.Len(t, Config.CoAuthors, 1)
+	assert.Equal(t, map[string][]string{"duo": {"jane"}}, Config.Groups)
+}
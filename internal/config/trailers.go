@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/spf13/viper"
+)
+
+// trailerKeyPattern matches the keys git accepts for trailers
+var trailerKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// validateTrailerKey checks the syntax of a custom trailer key
+func validateTrailerKey(key string) error {
+	if !trailerKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid trailer key '%s': use letters, digits and dashes, e.g. Acked-by", key)
+	}
+	return nil
+}
+
+// TrailerKeys returns the trailer keys co-authors can be credited with: the
+// built-in ones followed by those of the roster and the settings file
+func TrailerKeys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+
+	candidates := append(append(append([]string{}, models.BuiltinTrailerKeys...), Config.Trailers...), viper.GetStringSlice("trailers")...)
+	for _, key := range candidates {
+		if seen[strings.ToLower(key)] || validateTrailerKey(key) != nil {
+			continue
+		}
+		seen[strings.ToLower(key)] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// ResolveTrailerKey returns the configured spelling of a trailer key given in
+// any case, e.g. "Reviewed-by" for "reviewed-by"
+func ResolveTrailerKey(name string) (string, error) {
+	keys := TrailerKeys()
+	for _, key := range keys {
+		if strings.EqualFold(key, name) {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("unknown trailer '%s', use one of %s or add it to \"trailers\" in the roster", name, strings.Join(keys, ", "))
+}
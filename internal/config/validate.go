@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severities of the problems found in rosters
+const (
+	SeverityError   = "error"   // The roster cannot be loaded
+	SeverityWarning = "warning" // The roster loads, but probably does not do what was meant
+)
+
+// Problem is something wrong in a roster file, with its location
+type Problem struct {
+	Path     string
+	Line     int    // Starting at 1, 0 if unknown
+	Column   int    // Starting at 1, 0 if unknown
+	Key      string // Dotted path of the offending key, e.g. coauthors.jane.email
+	Message  string
+	Severity string
+}
+
+// Error formats the problem like a compiler, e.g. "/home/jane/.pair.json:4:7: message"
+func (p Problem) Error() string {
+	return p.Location() + ": " + p.Message
+}
+
+// Location returns the path with the line and column, as far as they are known
+func (p Problem) Location() string {
+	location := p.Path
+	if p.Line > 0 {
+		location += ":" + strconv.Itoa(p.Line)
+		if p.Column > 0 {
+			location += ":" + strconv.Itoa(p.Column)
+		}
+	}
+	return location
+}
+
+// report records a problem with the key of the layer
+func (l *layer) report(severity string, key []string, nth int, format string, args ...interface{}) {
+	l.problems = append(l.problems, l.problemAt(severity, key, nth, fmt.Sprintf(format, args...)))
+}
+
+// problemAt returns a problem with the nth occurrence of the key of the layer,
+// located in the file
+func (l *layer) problemAt(severity string, key []string, nth int, message string) Problem {
+	if l.positions == nil {
+		l.positions = locate(l.data, FormatOf(l.path))
+	}
+
+	problem := Problem{Path: l.path, Key: strings.Join(key, "."), Message: message, Severity: severity}
+	if pos, ok := l.positions.find(key, nth); ok {
+		problem.Line, problem.Column = pos.line, pos.column
+	}
+	return problem
+}
+
+// syntaxProblem records that the file could not be parsed at all
+func (l *layer) syntaxProblem(err error) {
+	problem := Problem{
+		Path:     l.path,
+		Message:  fmt.Sprintf("could not parse config file: %v", err),
+		Severity: SeverityError,
+	}
+	if pos, ok := errorPosition(l.data, FormatOf(l.path), err); ok {
+		problem.Line, problem.Column = pos.line, pos.column
+	}
+	l.problems = append(l.problems, problem)
+}
+
+// reportDuplicates records every key that appears more than once in a JSON or
+// YAML file, which both formats allow but would silently drop entries. It
+// reports whether there were any.
+func (l *layer) reportDuplicates() bool {
+	if FormatOf(l.path) == FormatTOML {
+		// Table headers repeat their parents, the TOML parser finds duplicates itself
+		return false
+	}
+	if l.positions == nil {
+		l.positions = locate(l.data, FormatOf(l.path))
+	}
+
+	var keys []string
+	for key, positions := range l.positions {
+		if len(positions) > 1 && !l.parentDuplicated(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := l.positions[keys[i]][1], l.positions[keys[j]][1]
+		return a.line < b.line || (a.line == b.line && a.column < b.column)
+	})
+
+	for _, key := range keys {
+		path := strings.Split(key, ".")
+		message := fmt.Sprintf("duplicate key '%s'", key)
+		switch {
+		case len(path) == 2 && path[0] == "coauthors":
+			message = fmt.Sprintf("duplicate alias '%s', only one co-author can use it", path[1])
+		case len(path) == 2 && path[0] == "groups":
+			message = fmt.Sprintf("duplicate group '%s', only one group can use the name", path[1])
+		}
+		for nth := 1; nth < len(l.positions[key]); nth++ {
+			l.report(SeverityError, path, nth, "%s", message)
+		}
+	}
+	return len(keys) > 0
+}
+
+// parentDuplicated reports whether a parent of the key appears more than once,
+// so the key is repeated along with it
+func (l *layer) parentDuplicated(key string) bool {
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+		if len(l.positions[key[:i]]) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// emailProblems warns about addresses used by more than one co-author of the
+// merged roster. Only the first co-author gets credited for such an address.
+func emailProblems(from origins) []Problem {
+	var problems []Problem
+
+	owners := map[string]string{} // Lowercase address to alias
+	for _, author := range Config.CoAuthors {
+		for i, email := range author.AllEmails() {
+			key := []string{"coauthors", author.Alias, "email"}
+			if i > 0 {
+				key = []string{"coauthors", author.Alias, "emails", strconv.Itoa(i - 1)}
+			}
+
+			address := strings.ToLower(email)
+			owner, taken := owners[address]
+			switch {
+			case !taken:
+				owners[address] = author.Alias
+			case owner == author.Alias:
+				problems = append(problems, from.coAuthors[author.Alias].problemAt(SeverityWarning, key, 0,
+					fmt.Sprintf("email '%s' is listed twice for '%s'", email, author.Alias)))
+			default:
+				problems = append(problems, from.coAuthors[author.Alias].problemAt(SeverityWarning, key, 0,
+					fmt.Sprintf("email '%s' of '%s' is also used by '%s', who gets the credit for it", email, author.Alias, owner)))
+			}
+		}
+	}
+
+	return problems
+}
+
+// Validate checks all roster files LoadConfig reads, or only ConfigPath if set,
+// and returns every problem found: in each file on its own, in the groups of the
+// merged roster and emails used by several co-authors. Like LoadConfig, it leaves
+// the merged roster in Config. It fails only if no roster can be read.
+func Validate() ([]Problem, error) {
+	candidates := []sourcePath{{path: ConfigPath, layer: LayerFile}}
+	if ConfigPath == "" {
+		candidates = sourcePaths()
+	}
+
+	var layers []*layer
+	var layerNames []string
+	var problems []Problem
+	for _, candidate := range candidates {
+		l, err := readLayer(candidate.path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && ConfigPath == "" {
+				continue
+			}
+			return nil, err
+		}
+		layers = append(layers, l)
+		layerNames = append(layerNames, candidate.layer)
+		problems = append(problems, l.problems...)
+	}
+
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("could not read config file: no roster found, create one with 'pair init': %w", fs.ErrNotExist)
+	}
+
+	from := combine(layers, layerNames)
+	problems = append(problems, groupProblems(from, nil)...)
+	problems = append(problems, emailProblems(from)...)
+	return problems, nil
+}
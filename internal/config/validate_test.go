@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// problemLines formats problems as ":line:column: severity key message" for comparison
+func problemLines(problems []Problem) []string {
+	lines := []string{}
+	for _, problem := range problems {
+		lines = append(lines, Problem{Line: problem.Line, Column: problem.Column, Message: problem.Severity + " " + problem.Key + " " + problem.Message}.Error())
+	}
+	return lines
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), "pair.json")
+	writeRoster(t, ConfigPath, `{
+  "trailers": ["Acked by"],
+  "coauthors": {
+    "jane": {"name": "Jane\nDoe", "email": "jane@"},
+    "john": {"name": "John Doe", "email": "John <john@example.com>",
+             "emails": ["jd@example.com", {"email": "j d@example.com"}]},
+    "42": {"name": "Answer", "email": "jd@example.com"}
+  },
+  "groups": {"squad": ["jane", "nobody"]}
+}`)
+
+	problems, err := Validate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		":2:16: error trailers.0 invalid trailer key 'Acked by': use letters, digits and dashes, e.g. Acked-by",
+		":4:14: error coauthors.jane.name invalid co-author 'jane': name must not contain line breaks or other control characters, use \"Jane Doe\"",
+		":4:35: error coauthors.jane.email invalid co-author 'jane': email has no domain after '@'",
+		":5:34: error coauthors.john.email invalid co-author 'john': email must be a plain address without name and angle brackets, use john@example.com",
+		":6:44: error coauthors.john.emails.1.email invalid co-author 'john': alternate email 'j d@example.com' must not contain whitespace or control characters",
+		":7:5: warning coauthors.42 alias '42' must not be a number, it cannot be selected by alias; rename it with 'pair config rename'",
+		":9:32: error groups.squad.1 invalid group 'squad': no co-author found with alias 'nobody'",
+		":7:30: warning coauthors.42.email email 'jd@example.com' of '42' is also used by 'john', who gets the credit for it",
+	}, problemLines(problems))
+
+	// Loading stops at the first error, with its location
+	assert.EqualError(t, LoadConfig(), ConfigPath+":2:16: invalid trailer key 'Acked by': use letters, digits and dashes, e.g. Acked-by")
+}
+
+func TestValidateLocatesProblemsInAllFormats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"pair.yaml": "coauthors:\n  jane:\n    name: Jane\n    email: jane@@example.com\n",
+		"pair.toml": "[coauthors.jane]\nname = \"Jane\"\nemail = \"jane@@example.com\"\n",
+		"pair.json": "{\"coauthors\": {\n  \"jane\": {\"name\": \"Jane\",\n    \"email\": \"jane@@example.com\"}}}",
+	}
+	want := map[string][]string{
+		"pair.yaml": {":4:5: error coauthors.jane.email invalid co-author 'jane': email has the invalid character '@' in the local part 'jane@'"},
+		"pair.toml": {":3:1: error coauthors.jane.email invalid co-author 'jane': email has the invalid character '@' in the local part 'jane@'"},
+		"pair.json": {":3:5: error coauthors.jane.email invalid co-author 'jane': email has the invalid character '@' in the local part 'jane@'"},
+	}
+
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			ConfigPath = filepath.Join(dir, name)
+			writeRoster(t, ConfigPath, data)
+
+			problems, err := Validate()
+			require.NoError(t, err)
+			assert.Equal(t, want[name], problemLines(problems))
+		})
+	}
+}
+
+func TestValidateReportsSyntaxErrorsAndDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"broken.json", "{\n  \"coauthors\": {,}\n}", []string{":2:17: error  could not parse config file: invalid character ',' looking for beginning of object key string"}},
+		{"broken.yaml", "coauthors:\n  jane: [\n", []string{":2: error  could not parse config file: yaml: line 2: did not find expected node content"}},
+		{"broken.toml", "[coauthors\n", []string{":1:11: error  could not parse config file: expected character ]"}},
+		{"duplicate.json", `{"coauthors": {
+  "jane": {"name": "Jane", "email": "jane@example.com"},
+  "jane": {"name": "Jane", "email": "jane@example.com"}
+}}`, []string{":3:3: error coauthors.jane duplicate alias 'jane', only one co-author can use it"}},
+		{"duplicate.yaml", "groups:\n  squad: [jane]\n  squad: [john]\n", []string{":3:3: error groups.squad duplicate group 'squad', only one group can use the name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ConfigPath = filepath.Join(dir, tt.name)
+			writeRoster(t, ConfigPath, tt.data)
+
+			problems, err := Validate()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, problemLines(problems))
+		})
+	}
+}
+
+func TestValidateMergesLayers(t *testing.T) {
+	repo, _ := setupLayers(t)
+	home := os.Getenv("HOME")
+
+	writeRoster(t, filepath.Join(home, ".pair.json"), `{"coauthors": {
+		"jane": {"name": "Jane Doe", "email": "jane@example.com"}
+	}}`)
+	writeRoster(t, filepath.Join(repo, ".pair.json"), `{
+  "coauthors": {"jd": {"name": "Jane", "email": "JANE@example.com"}},
+  "groups": {"squad": ["jane", "john"]}
+}`)
+
+	problems, err := Validate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		":3:32: error groups.squad.1 invalid group 'squad': no co-author found with alias 'john'",
+		":2:40: warning coauthors.jd.email email 'JANE@example.com' of 'jd' is also used by 'jane', who gets the credit for it",
+	}, problemLines(problems))
+	assert.Equal(t, filepath.Join(repo, ".pair.json"), problems[0].Path)
+}
+
+func TestValidateWithoutRoster(t *testing.T) {
+	setupLayers(t)
+
+	_, err := Validate()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestWhitespaceInNamesIsAWarning(t *testing.T) {
+	ConfigPath = filepath.Join(t.TempDir(), "pair.json")
+	writeRoster(t, ConfigPath, `{"coauthors": {
+  "jane": {"name": "Jane  Doe", "email": "jane@example.com"},
+  "john": {"name": " John Doe", "email": "john@example.com"}
+}}`)
+
+	problems, err := Validate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`:2:12: warning coauthors.jane.name co-author 'jane': name should separate words with single spaces, trailers use "Jane Doe"`,
+		`:3:12: warning coauthors.john.name co-author 'john': name should not start or end with whitespace, trailers use "John Doe"`,
+	}, problemLines(problems))
+
+	// The roster still loads, with the names the way trailers write them
+	require.NoError(t, LoadConfig())
+	assert.Equal(t, "Jane Doe", Config.CoAuthorsMap["jane"].Name)
+	assert.Equal(t, "John Doe", Config.CoAuthors[1].Name)
+}
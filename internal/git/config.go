@@ -0,0 +1,44 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigGet returns the value of key, or an empty string if it is unset.
+// Options such as "--global" select the configuration file to read from.
+func ConfigGet(key string, options ...string) (string, error) {
+	args := append(append([]string{"config"}, options...), "--get", key)
+	output, err := Run(args...)
+	if err != nil {
+		// Exit code 1 means the key is not set
+		if ExitCode(err) == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// ConfigSet sets key to value in the configuration file selected by options
+func ConfigSet(key, value string, options ...string) error {
+	args := append(append([]string{"config"}, options...), key, value)
+	if _, err := Run(args...); err != nil {
+		return fmt.Errorf("failed to set git %s: %w", key, err)
+	}
+	return nil
+}
+
+// ConfigUnset removes key from the configuration file selected by options,
+// ignoring keys that are not set
+func ConfigUnset(key string, options ...string) error {
+	args := append(append([]string{"config"}, options...), "--unset", key)
+	if _, err := Run(args...); err != nil {
+		// Exit code 5 means the section or key doesn't exist, which is fine
+		if ExitCode(err) == 5 {
+			return nil
+		}
+		return fmt.Errorf("failed to unset git %s: %w", key, err)
+	}
+	return nil
+}
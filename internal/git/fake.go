@@ -0,0 +1,208 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// configScopes lists the configuration files from lowest to highest precedence
+var configScopes = []string{"system", "global", "local", "worktree"}
+
+// Fake is an in-memory Runner for tests. It implements the subset of
+// "git config", "git rev-parse" and "git check-mailmap" used by pair; any
+// other subcommand, and rev-parse options other than the repository discovery
+// ones, are dispatched to Handlers.
+type Fake struct {
+	// Config holds the configuration per scope ("system", "global", "local", "worktree").
+	// Like git, --worktree is refused unless extensions.worktreeConfig is enabled.
+	Config map[string]map[string]string
+	// GitDir is returned for --git-dir and --git-common-dir
+	GitDir string
+	// Mailmap maps lower-cased emails to canonical contacts such as "Jane <jane@example.com>"
+	Mailmap map[string]string
+	// Handlers implement further subcommands, keyed by subcommand name
+	Handlers map[string]func(cmd Command) (string, error)
+	// Calls records the arguments of every invocation
+	Calls [][]string
+}
+
+// NewFake returns a Fake with empty configuration
+func NewFake() *Fake {
+	f := &Fake{
+		Config:   make(map[string]map[string]string),
+		Mailmap:  make(map[string]string),
+		Handlers: make(map[string]func(cmd Command) (string, error)),
+	}
+	for _, scope := range configScopes {
+		f.Config[scope] = make(map[string]string)
+	}
+	return f
+}
+
+// Run dispatches the command to the in-memory implementation
+func (f *Fake) Run(cmd Command) (string, error) {
+	f.Calls = append(f.Calls, cmd.Args)
+
+	if len(cmd.Args) == 0 {
+		return "", f.fail(cmd.Args, 1, "no command given")
+	}
+
+	switch cmd.Args[0] {
+	case "config":
+		return f.config(cmd.Args)
+	case "rev-parse":
+		return f.revParse(cmd.Args)
+	case "check-mailmap":
+		return f.checkMailmap(cmd)
+	}
+
+	if handler, ok := f.Handlers[cmd.Args[0]]; ok {
+		return handler(cmd)
+	}
+	return "", f.fail(cmd.Args, 1, fmt.Sprintf("'%s' is not implemented by the fake", cmd.Args[0]))
+}
+
+func (f *Fake) fail(args []string, code int, stderr string) error {
+	return &ExitError{Args: args, Code: code, Stderr: stderr}
+}
+
+// config implements "git config [--<scope>] (--get key | --unset key | key value)"
+func (f *Fake) config(args []string) (string, error) {
+	scope := ""
+	action := "set"
+	showScope := false
+	var rest []string
+
+	for _, arg := range args[1:] {
+		switch arg {
+		case "--worktree":
+			// Git refuses it once there are linked worktrees, which is when it matters
+			if !f.worktreeConfig() {
+				return "", f.fail(args, 128, "fatal: --worktree cannot be used with multiple working trees unless the config\n"+
+					"extension worktreeConfig is enabled. Please read \"CONFIGURATION FILE\"\n"+
+					"section in \"git help worktree\" for details")
+			}
+			scope = "worktree"
+		case "--system", "--global", "--local":
+			scope = strings.TrimPrefix(arg, "--")
+		case "--get":
+			action = "get"
+		case "--show-scope":
+			showScope = true
+		case "--unset":
+			action = "unset"
+		default:
+			rest = append(rest, arg)
+		}
+	}
+
+	switch action {
+	case "get":
+		if len(rest) != 1 {
+			return "", f.fail(args, 129, "wrong number of arguments")
+		}
+		key := strings.ToLower(rest[0])
+		scopes := []string{scope}
+		if scope == "" {
+			// Without a scope the value with the highest precedence wins
+			scopes = nil
+			for i := len(configScopes) - 1; i >= 0; i-- {
+				scopes = append(scopes, configScopes[i])
+			}
+		}
+		for _, candidate := range scopes {
+			if value, ok := f.Config[candidate][key]; ok {
+				if showScope {
+					return candidate + "\t" + value + "\n", nil
+				}
+				return value + "\n", nil
+			}
+		}
+		return "", f.fail(args, 1, "")
+	case "unset":
+		if len(rest) != 1 {
+			return "", f.fail(args, 129, "wrong number of arguments")
+		}
+		if scope == "" {
+			scope = "local"
+		}
+		key := strings.ToLower(rest[0])
+		if _, ok := f.Config[scope][key]; !ok {
+			return "", f.fail(args, 5, "")
+		}
+		delete(f.Config[scope], key)
+		return "", nil
+	default:
+		if len(rest) != 2 {
+			return "", f.fail(args, 129, "wrong number of arguments")
+		}
+		if scope == "" {
+			scope = "local"
+		}
+		f.Config[scope][strings.ToLower(rest[0])] = rest[1]
+		return "", nil
+	}
+}
+
+// worktreeConfig reports whether extensions.worktreeConfig is enabled
+func (f *Fake) worktreeConfig() bool {
+	switch strings.ToLower(f.Config["local"]["extensions.worktreeconfig"]) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
+
+// revParse implements the repository discovery options of "git rev-parse"
+func (f *Fake) revParse(args []string) (string, error) {
+	if f.GitDir == "" {
+		return "", f.fail(args, 128, "fatal: not a git repository")
+	}
+
+	var out strings.Builder
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--git-dir", "--git-common-dir":
+			out.WriteString(f.GitDir + "\n")
+		case "--show-toplevel":
+			out.WriteString(strings.TrimSuffix(f.GitDir, "/.git") + "\n")
+		case "--git-path":
+			if i+1 >= len(args) {
+				return "", f.fail(args, 128, "missing path")
+			}
+			i++
+			if args[i] == "hooks" {
+				if hooksPath, err := f.config([]string{"config", "--get", "core.hooksPath"}); err == nil {
+					out.WriteString(hooksPath)
+					continue
+				}
+			}
+			out.WriteString(f.GitDir + "/" + args[i] + "\n")
+		default:
+			// Resolving revisions is left to the tests that need it
+			if handler, ok := f.Handlers["rev-parse"]; ok {
+				return handler(Command{Args: args})
+			}
+			return "", f.fail(args, 128, fmt.Sprintf("unsupported option %s", args[i]))
+		}
+	}
+	return out.String(), nil
+}
+
+// checkMailmap implements "git check-mailmap --stdin" using Mailmap
+func (f *Fake) checkMailmap(cmd Command) (string, error) {
+	if len(cmd.Args) != 2 || cmd.Args[1] != "--stdin" {
+		return "", f.fail(cmd.Args, 129, "only --stdin is supported")
+	}
+
+	var out strings.Builder
+	for _, contact := range strings.Split(strings.TrimRight(cmd.Stdin, "\n"), "\n") {
+		_, email, _ := strings.Cut(contact, "<")
+		email = strings.ToLower(strings.TrimSuffix(email, ">"))
+		if canonical, ok := f.Mailmap[email]; ok {
+			contact = canonical
+		}
+		out.WriteString(contact + "\n")
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,25 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckMailmap maps contacts of the form "Name <email>" through the .mailmap of
+// the repository. The result holds the canonical contact for each input, in order.
+func CheckMailmap(contacts []string) ([]string, error) {
+	if len(contacts) == 0 {
+		return nil, nil
+	}
+
+	output, err := RunInput(strings.Join(contacts, "\n")+"\n", "check-mailmap", "--stdin")
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply .mailmap: %w", err)
+	}
+
+	mapped := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(mapped) != len(contacts) {
+		return nil, fmt.Errorf("failed to apply .mailmap: expected %d contacts, got %d", len(contacts), len(mapped))
+	}
+	return mapped, nil
+}
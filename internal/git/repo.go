@@ -0,0 +1,65 @@
+package git
+
+import (
+	"net/url"
+	"strings"
+)
+
+// TopLevel returns the top-level directory of the working tree, or an empty
+// string outside of a working tree
+func TopLevel() string {
+	output, err := Run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// RemoteHosts returns the hosts of all remote URLs of the repository, each once
+func RemoteHosts() []string {
+	output, err := Run("remote", "-v")
+	if err != nil {
+		return nil
+	}
+
+	var hosts []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if host := urlHost(fields[1]); host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// urlHost extracts the host of a remote URL such as https://github.com/owner/repo.git
+// or the scp-like git@github.com:owner/repo.git. Local paths have no host.
+func urlHost(remoteURL string) string {
+	if strings.Contains(remoteURL, "://") {
+		parsed, err := url.Parse(remoteURL)
+		if err != nil {
+			return ""
+		}
+		return strings.ToLower(parsed.Hostname())
+	}
+
+	// scp-like syntax, a colon before the first slash
+	colon := strings.Index(remoteURL, ":")
+	if colon < 0 || strings.Contains(remoteURL[:colon], "/") {
+		return ""
+	}
+	host := remoteURL[:colon]
+	if len(host) == 1 {
+		// A Windows drive letter
+		return ""
+	}
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return strings.ToLower(host)
+}
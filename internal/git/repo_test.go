@@ -0,0 +1,24 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLHost(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/owner/repo.git":     "github.com",
+		"ssh://git@GitLab.example.com:2222/o/r": "gitlab.example.com",
+		"git@github.com:owner/repo.git":         "github.com",
+		"github.com:owner/repo.git":             "github.com",
+		"/srv/git/repo.git":                     "",
+		"../relative/repo":                      "",
+		"C:/repos/project":                      "",
+		"file:///srv/git/repo.git":              "",
+	}
+
+	for remoteURL, want := range tests {
+		assert.Equal(t, want, urlHost(remoteURL), remoteURL)
+	}
+}
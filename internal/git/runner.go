@@ -0,0 +1,92 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Command describes a single git invocation
+type Command struct {
+	Args  []string // Arguments passed to git, without the leading "git"
+	Stdin string   // Data written to the standard input of git
+	Env   []string // Additional environment variables in KEY=value form
+}
+
+// Runner executes git commands. The exec backed runner is used by default,
+// tests swap in a Fake.
+type Runner interface {
+	Run(cmd Command) (string, error)
+}
+
+// Default is the runner used by all git helpers
+var Default Runner = ExecRunner{}
+
+// ExitError is returned when git exits with a non-zero status
+type ExitError struct {
+	Args   []string
+	Code   int
+	Stderr string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("git %s: exit status %d", strings.Join(e.Args, " "), e.Code)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// ExitCode returns the exit status carried by err, or -1 if err is not an ExitError
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return -1
+}
+
+// Run executes git with the given arguments using the default runner
+func Run(args ...string) (string, error) {
+	return Default.Run(Command{Args: args})
+}
+
+// RunInput executes git with the given arguments and standard input using the default runner
+func RunInput(stdin string, args ...string) (string, error) {
+	return Default.Run(Command{Args: args, Stdin: stdin})
+}
+
+// ExecRunner runs the git binary found in PATH
+type ExecRunner struct{}
+
+// Run executes the command and returns its standard output
+func (ExecRunner) Run(c Command) (string, error) {
+	cmd := exec.Command("git", c.Args...)
+	if c.Stdin != "" {
+		cmd.Stdin = strings.NewReader(c.Stdin)
+	}
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return stdout.String(), &ExitError{
+				Args:   c.Args,
+				Code:   exitErr.ExitCode(),
+				Stderr: strings.TrimSpace(stderr.String()),
+			}
+		}
+		return stdout.String(), fmt.Errorf("could not run git: %w", err)
+	}
+
+	return stdout.String(), nil
+}
@@ -0,0 +1,199 @@
+package githook
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+)
+
+const (
+	// hookName is the git hook pair installs
+	hookName = "prepare-commit-msg"
+
+	// chainedSuffix is appended to a hook that existed before pair was installed.
+	// The pair hook runs it first so both can coexist.
+	chainedSuffix = ".pair-chained"
+
+	// marker identifies hooks written by pair
+	marker = "# Installed by pair"
+)
+
+// hookScript runs a previously installed hook first and then lets pair append
+// the active co-authors to the commit message
+const hookScript = `#!/bin/sh
+` + marker + `. Remove with: pair hook uninstall
+hook_dir=$(dirname "$0")
+if [ -x "$hook_dir/` + hookName + chainedSuffix + `" ]; then
+	"$hook_dir/` + hookName + chainedSuffix + `" "$@" || exit $?
+fi
+if command -v pair >/dev/null 2>&1; then
+	pair hook run "$@" || exit $?
+fi
+`
+
+// HooksDir returns the directory git runs hooks from, honoring core.hooksPath
+func HooksDir() (string, error) {
+	output, err := git.Run("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", fmt.Errorf("hooks require a git repository: %w", err)
+	}
+
+	dir := strings.TrimSpace(output)
+	if strings.HasPrefix(dir, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("could not get home directory: %w", err)
+		}
+		dir = filepath.Join(homeDir, dir[2:])
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve hooks directory: %w", err)
+	}
+	return dir, nil
+}
+
+// isPairHook reports whether the hook at path was written by pair
+func isPairHook(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), marker)
+}
+
+// Installed reports whether the pair hook is installed and returns its path
+func Installed() (bool, string, error) {
+	dir, err := HooksDir()
+	if err != nil {
+		return false, "", err
+	}
+	path := filepath.Join(dir, hookName)
+	return isPairHook(path), path, nil
+}
+
+// Install writes the prepare-commit-msg hook. An existing hook that was not written
+// by pair is kept and chained, so it still runs before pair's one.
+func Install() (string, error) {
+	dir, err := HooksDir()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("could not create hooks directory: %w", err)
+	}
+
+	path := filepath.Join(dir, hookName)
+	if _, err := os.Stat(path); err == nil && !isPairHook(path) {
+		chained := path + chainedSuffix
+		if _, err := os.Stat(chained); err == nil {
+			return "", fmt.Errorf("cannot chain existing hook: %s already exists", chained)
+		}
+		if err := os.Rename(path, chained); err != nil {
+			return "", fmt.Errorf("could not move existing hook: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(hookScript), 0755); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+
+	return path, nil
+}
+
+// Uninstall removes the pair hook and puts a chained hook back in its place
+func Uninstall() (string, error) {
+	installed, path, err := Installed()
+	if err != nil {
+		return "", err
+	}
+
+	if !installed {
+		if _, err := os.Stat(path); err == nil {
+			return "", fmt.Errorf("%s was not installed by pair, leaving it untouched", path)
+		}
+		return "", fmt.Errorf("no pair hook installed in %s", filepath.Dir(path))
+	}
+
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove hook: %w", err)
+	}
+
+	chained := path + chainedSuffix
+	if _, err := os.Stat(chained); err == nil {
+		if err := os.Rename(chained, path); err != nil {
+			return "", fmt.Errorf("could not restore previous hook: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// AppendTrailers adds the trailer of each co-author, Co-authored-by by default,
+// to the commit message file, skipping trailers that are already present
+func AppendTrailers(messagePath string, coAuthors []models.CoAuthor) error {
+	if len(coAuthors) == 0 {
+		return nil
+	}
+
+	args := []string{"interpret-trailers", "--in-place", "--if-exists", "addIfDifferent"}
+	for _, author := range coAuthors {
+		args = append(args, "--trailer", author.Trailer())
+	}
+	args = append(args, messagePath)
+
+	if _, err := git.Run(args...); err != nil {
+		return fmt.Errorf("failed to add trailers: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveTrailers removes the trailer of each co-author from the commit message
+// file, e.g. the ones the commit template added with an email that another one
+// replaces for this repository
+func RemoveTrailers(messagePath string, coAuthors []models.CoAuthor) error {
+	if len(coAuthors) == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(messagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message: %w", err)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !isTrailerOf(strings.TrimSpace(line), coAuthors) {
+			kept = append(kept, line)
+		}
+	}
+	if len(kept) == len(lines) {
+		return nil
+	}
+
+	if err := os.WriteFile(messagePath, []byte(strings.Join(kept, "")), 0644); err != nil {
+		return fmt.Errorf("failed to write commit message: %w", err)
+	}
+	return nil
+}
+
+// isTrailerOf reports whether line is the trailer of one of the co-authors
+func isTrailerOf(line string, coAuthors []models.CoAuthor) bool {
+	for _, author := range coAuthors {
+		if strings.EqualFold(line, author.Trailer()) {
+			return true
+		}
+	}
+	return false
+}
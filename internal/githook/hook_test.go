@@ -0,0 +1,155 @@
+package githook
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRepo creates an empty repository and changes into it
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	_, err = git.Run("init", "--quiet")
+	require.NoError(t, err)
+
+	// Resolve symbolic links such as /tmp on macOS, git reports resolved paths
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+	return dir
+}
+
+func TestInstallAndUninstall(t *testing.T) {
+	dir := setupRepo(t)
+	hook := filepath.Join(dir, ".git", "hooks", hookName)
+
+	installed, path, err := Installed()
+	require.NoError(t, err)
+	assert.False(t, installed)
+	assert.Equal(t, hook, path)
+
+	path, err = Install()
+	require.NoError(t, err)
+	assert.Equal(t, hook, path)
+
+	installed, _, err = Installed()
+	require.NoError(t, err)
+	assert.True(t, installed)
+
+	info, err := os.Stat(hook)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100, "hook must be executable")
+
+	// Installing again replaces the hook instead of chaining it to itself
+	_, err = Install()
+	require.NoError(t, err)
+	assert.NoFileExists(t, hook+chainedSuffix)
+
+	_, err = Uninstall()
+	require.NoError(t, err)
+	assert.NoFileExists(t, hook)
+
+	_, err = Uninstall()
+	assert.ErrorContains(t, err, "no pair hook installed")
+}
+
+func TestInstallChainsExistingHook(t *testing.T) {
+	dir := setupRepo(t)
+	hook := filepath.Join(dir, ".git", "hooks", hookName)
+	own := "#!/bin/sh\necho own\n"
+	require.NoError(t, os.MkdirAll(filepath.Dir(hook), 0755))
+	require.NoError(t, os.WriteFile(hook, []byte(own), 0755))
+
+	_, err := Uninstall()
+	assert.ErrorContains(t, err, "was not installed by pair")
+
+	_, err = Install()
+	require.NoError(t, err)
+
+	chained, err := os.ReadFile(hook + chainedSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, own, string(chained))
+	assert.True(t, isPairHook(hook))
+
+	// A second existing hook cannot be chained as well
+	require.NoError(t, os.WriteFile(hook, []byte(own), 0755))
+	_, err = Install()
+	assert.ErrorContains(t, err, "already exists")
+	require.NoError(t, os.Remove(hook))
+	_, err = Install()
+	require.NoError(t, err)
+
+	// Uninstalling puts the previous hook back
+	_, err = Uninstall()
+	require.NoError(t, err)
+	restored, err := os.ReadFile(hook)
+	require.NoError(t, err)
+	assert.Equal(t, own, string(restored))
+	assert.NoFileExists(t, hook+chainedSuffix)
+}
+
+func TestInstallHonorsHooksPath(t *testing.T) {
+	dir := setupRepo(t)
+	_, err := git.Run("config", "core.hooksPath", "shared-hooks")
+	require.NoError(t, err)
+
+	path, err := Install()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "shared-hooks", hookName), path)
+	assert.FileExists(t, path)
+	assert.NoFileExists(t, filepath.Join(dir, ".git", "hooks", hookName))
+}
+
+func TestAppendTrailers(t *testing.T) {
+	dir := setupRepo(t)
+	message := filepath.Join(dir, "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(message, []byte("Fix the build\n\nCo-authored-by: Jane Doe <jane@example.com>\n"), 0644))
+
+	jane := models.CoAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+	john := models.CoAuthor{Name: "John Doe", Email: "john@example.com", TrailerKey: "Reviewed-by"}
+	require.NoError(t, AppendTrailers(message, []models.CoAuthor{jane, john}))
+
+	// Trailers already present are not added twice
+	data, err := os.ReadFile(message)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix the build\n\nCo-authored-by: Jane Doe <jane@example.com>\nReviewed-by: John Doe <john@example.com>\n", string(data))
+
+	require.NoError(t, AppendTrailers(message, []models.CoAuthor{jane, john}))
+	again, err := os.ReadFile(message)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+
+	// Nothing to append leaves the message untouched
+	require.NoError(t, AppendTrailers(message, nil))
+}
+
+func TestRemoveTrailers(t *testing.T) {
+	message := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+	require.NoError(t, os.WriteFile(message, []byte("Fix the build\n\nco-authored-by: Jane Doe <jane@company.example.com>\nReviewed-by: Jane Doe <jane@company.example.com>\n"), 0644))
+
+	jane := models.CoAuthor{Name: "Jane Doe", Email: "jane@company.example.com"}
+	require.NoError(t, RemoveTrailers(message, []models.CoAuthor{jane}))
+
+	// Only the trailer of the co-author is removed, not other trailers of the same person
+	data, err := os.ReadFile(message)
+	require.NoError(t, err)
+	assert.Equal(t, "Fix the build\n\nReviewed-by: Jane Doe <jane@company.example.com>\n", string(data))
+}
@@ -0,0 +1,162 @@
+package gitimport
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/identity"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/philippeckel/pair/internal/stats"
+	"github.com/philippeckel/pair/internal/trailer"
+)
+
+// Candidate is a person found in the history who is not in the roster yet
+type Candidate struct {
+	models.CoAuthor     // Alias holds the proposed alias
+	Commits         int // Commits the person authored or co-authored
+}
+
+// Candidates returns everyone who authored or co-authored a commit reachable from
+// HEAD and whose email is not in the roster, most active first. Identities are
+// mapped through .mailmap and deduplicated by email. The proposed aliases do not
+// collide with the taken ones, which should hold the roster aliases and group names.
+// The known trailer keys help to find the trailers of the commits.
+func Candidates(roster []models.CoAuthor, taken, keys []string) ([]Candidate, error) {
+	commits, err := stats.Log(stats.Window{}, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count the commits of every contact as written in the history
+	var contacts []string
+	counts := map[string]int{}
+	for _, commit := range commits {
+		for _, person := range commit.Participants() {
+			contact := trailer.Ident(person.Name, person.Email)
+			if _, ok := counts[contact]; !ok {
+				contacts = append(contacts, contact)
+			}
+			counts[contact]++
+		}
+	}
+
+	mapped, err := git.CheckMailmap(contacts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Alternate emails of roster entries are known as well
+	matcher := identity.NewMatcher(roster)
+
+	// The log is newest first, so the most recent name of an email is kept
+	var candidates []*Candidate
+	byEmail := map[string]*Candidate{}
+	for i, contact := range mapped {
+		person, ok := parseContact(contact)
+		if !ok || isBot(person) {
+			continue
+		}
+
+		if _, known := matcher.Lookup(person.Email); known {
+			continue
+		}
+
+		key := strings.ToLower(person.Email)
+
+		candidate, ok := byEmail[key]
+		if !ok {
+			candidate = &Candidate{CoAuthor: person}
+			byEmail[key] = candidate
+			candidates = append(candidates, candidate)
+		}
+		candidate.Commits += counts[contacts[i]]
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Commits > candidates[j].Commits
+	})
+
+	usedAliases := map[string]bool{}
+	for _, alias := range taken {
+		usedAliases[alias] = true
+	}
+
+	result := make([]Candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidate.Alias = ProposeAlias(candidate.Name, candidate.Email, usedAliases)
+		usedAliases[candidate.Alias] = true
+		result = append(result, *candidate)
+	}
+	return result, nil
+}
+
+// parseContact splits a contact of the form "Name <email>"
+func parseContact(contact string) (models.CoAuthor, bool) {
+	name, email, ok := trailer.ParseIdent(contact)
+	if !ok {
+		return models.CoAuthor{}, false
+	}
+
+	person := models.CoAuthor{Name: name, Email: email}
+	return person, person.Validate() == nil
+}
+
+// isBot reports whether the person is an automated account such as dependabot[bot]
+func isBot(person models.CoAuthor) bool {
+	return strings.HasSuffix(person.Name, "[bot]")
+}
+
+// ProposeAlias derives an alias from the first name, lower-cased. If the alias is
+// taken, the initial of the last name and then a number are appended. Without a
+// usable name the local part of the email is used instead.
+func ProposeAlias(name, email string, taken map[string]bool) string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		words[i] = aliasWord(word)
+	}
+
+	base := ""
+	if len(words) > 0 {
+		base = words[0]
+	}
+	if base == "" {
+		local, _, _ := strings.Cut(email, "@")
+		base = aliasWord(local)
+	}
+	if base == "" {
+		base = "coauthor"
+	}
+
+	if !taken[base] {
+		return base
+	}
+
+	if len(words) > 1 && words[len(words)-1] != "" {
+		withInitial := base + string([]rune(words[len(words)-1])[0])
+		if !taken[withInitial] {
+			return withInitial
+		}
+		base = withInitial
+	}
+
+	for n := 2; ; n++ {
+		alias := fmt.Sprintf("%s%d", base, n)
+		if !taken[alias] {
+			return alias
+		}
+	}
+}
+
+// aliasWord lower-cases a word and drops everything but letters and digits
+func aliasWord(word string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
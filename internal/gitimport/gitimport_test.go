@@ -0,0 +1,69 @@
+package gitimport
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposeAlias(t *testing.T) {
+	taken := map[string]bool{"jane": true, "janed": true, "sam": true}
+
+	tests := []struct {
+		name, email, want string
+	}{
+		{"John Doe", "john@example.com", "john"},
+		{"Jane Doe", "jane@example.com", "janed2"},
+		{"Jane Smith", "jane.smith@example.com", "janes"},
+		{"Sam", "sam@example.com", "sam2"},
+		{"O'Brien, Pat", "pat@example.com", "obrien"},
+		{"", "kim.lee@example.com", "kimlee"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, ProposeAlias(tt.name, tt.email, taken))
+		})
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	run := func(args ...string) {
+		_, err := git.Run(args...)
+		require.NoError(t, err)
+	}
+	run("init", "--quiet")
+	run("config", "user.name", "Jane Doe")
+	run("config", "user.email", "jane@example.com")
+	run("commit", "--quiet", "--allow-empty", "-m", "one", "--author", "J. Doe <jdoe@old.example.com>")
+	run("commit", "--quiet", "--allow-empty", "-m", "two\n\nCo-authored-by: John Doe <john@example.com>")
+	run("commit", "--quiet", "--allow-empty", "-m", "three\n\nCo-authored-by: dependabot[bot] <bot@example.com>\nCo-authored-by: Sam <sam@example.com>")
+	require.NoError(t, os.WriteFile(".mailmap", []byte("Jane Doe <jane@example.com> <jdoe@old.example.com>\n"), 0644))
+
+	roster := []models.CoAuthor{{Alias: "sam", Name: "Sam", Email: "SAM@example.com"}}
+	candidates, err := Candidates(roster, []string{"sam", "john"}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Candidate{
+		{CoAuthor: models.CoAuthor{Alias: "jane", Name: "Jane Doe", Email: "jane@example.com"}, Commits: 3},
+		{CoAuthor: models.CoAuthor{Alias: "johnd", Name: "John Doe", Email: "john@example.com"}, Commits: 1},
+	}, candidates)
+}
@@ -5,35 +5,107 @@ import (
 	"strings"
 
 	"github.com/philippeckel/pair/internal/models"
+	"github.com/philippeckel/pair/internal/trailer"
 )
 
-// ParseActiveCoAuthors extracts co-authors from the current git template
-func ParseActiveCoAuthors(templatePath string) ([]models.CoAuthor, error) {
+// readBlock returns the lines of the co-author block written by pair, keeping
+// their indentation so continuation lines can be told apart. A template that
+// was not written by pair has no block and yields no lines.
+func readBlock(templatePath string) ([]string, error) {
+	data, err := os.ReadFile(expandPath(templatePath))
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != blockStart {
+			continue
+		}
+
+		var block []string
+		for _, blockLine := range lines[i+1:] {
+			blockLine = strings.TrimRight(blockLine, " \t\r")
+			if strings.TrimSpace(blockLine) == blockEnd {
+				break
+			}
+			block = append(block, blockLine)
+		}
+		return block, nil
+	}
+
+	return nil, nil
+}
+
+// ParseActiveCoAuthors extracts co-authors from the current git template.
+// Only the co-author block written by pair is considered, so a template that was
+// not written by pair has no active co-authors. Every trailer of the block is an
+// active co-author, with its TrailerKey set in the spelling of the known keys.
+func ParseActiveCoAuthors(templatePath string, keys []string) ([]models.CoAuthor, error) {
 	var activeCoAuthors []models.CoAuthor
 
 	if templatePath == "" {
 		return activeCoAuthors, nil
 	}
 
-	data, err := os.ReadFile(templatePath)
+	lines, err := readBlock(templatePath)
 	if err != nil {
 		return nil, err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "Co-authored-by:") {
-			// Extract name and email from format: "Co-authored-by: Name <email>"
-			authorInfo := strings.TrimSpace(strings.TrimPrefix(line, "Co-authored-by:"))
-			parts := strings.Split(authorInfo, "<")
-			if len(parts) == 2 {
-				name := strings.TrimSpace(parts[0])
-				email := strings.TrimSpace(strings.TrimSuffix(parts[1], ">"))
-				activeCoAuthors = append(activeCoAuthors, models.CoAuthor{Name: name, Email: email})
+	return ParseTrailers(lines, keys), nil
+}
+
+// ParseTrailers extracts the people credited by "Key: Name <email>" trailer
+// lines of any key, ignoring all other lines. Keys are matched case-insensitively
+// and the known ones, see config.TrailerKeys, are returned in their spelling.
+func ParseTrailers(lines []string, keys []string) []models.CoAuthor {
+	return credited(trailer.ParseLines(lines), keys)
+}
+
+// ParseCoAuthors extracts the co-authors from Co-authored-by trailer lines,
+// ignoring all other lines. The trailer key is matched case-insensitively.
+func ParseCoAuthors(lines []string) []models.CoAuthor {
+	return coAuthorsOnly(trailer.ParseLines(lines))
+}
+
+// ParseMessageCoAuthors extracts the co-authors credited in the trailer block
+// of a commit message, the way git interpret-trailers finds it with the known keys
+func ParseMessageCoAuthors(message string, keys []string) []models.CoAuthor {
+	return coAuthorsOnly(trailer.Parse(message, keys))
+}
+
+// credited returns the people credited by the trailers, with their TrailerKey set.
+// Values that are not of the format "Name <email>" are skipped.
+func credited(trailers []trailer.Trailer, keys []string) []models.CoAuthor {
+	var coAuthors []models.CoAuthor
+	for _, t := range trailers {
+		name, email, ok := trailer.ParseIdent(t.Value)
+		if !ok {
+			continue
+		}
+
+		coAuthor := models.CoAuthor{Name: name, Email: email, TrailerKey: t.Key}
+		for _, known := range keys {
+			if strings.EqualFold(known, t.Key) {
+				coAuthor.TrailerKey = known
 			}
 		}
+		coAuthors = append(coAuthors, coAuthor)
+	}
+
+	return coAuthors
+}
+
+// coAuthorsOnly returns the people credited by Co-authored-by trailers
+func coAuthorsOnly(trailers []trailer.Trailer) []models.CoAuthor {
+	var coAuthors []models.CoAuthor
+	for _, coAuthor := range credited(trailers, nil) {
+		if coAuthor.IsCoAuthor() {
+			coAuthor.TrailerKey = ""
+			coAuthors = append(coAuthors, coAuthor)
+		}
 	}
 
-	return activeCoAuthors, nil
+	return coAuthors
 }
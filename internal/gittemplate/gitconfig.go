@@ -0,0 +1,81 @@
+package gittemplate
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/git"
+)
+
+// errNoWorktreeConfig is returned when the worktree scope is written in a
+// repository without per-worktree configuration
+var errNoWorktreeConfig = errors.New("worktree scope requires per-worktree git configuration, " +
+	"enable it with: git config extensions.worktreeConfig true")
+
+// scopeOptions returns the git config options selecting the scope.
+// An empty scope reads the effective value git itself would use.
+func scopeOptions(scope Scope) []string {
+	if scope == "" {
+		return nil
+	}
+	return []string{scope.flag()}
+}
+
+// worktreeConfig reports whether the repository has per-worktree configuration.
+// Without it git refuses --worktree as soon as there are linked worktrees, and
+// writes to the configuration of the repository otherwise.
+func worktreeConfig() (bool, error) {
+	value, err := git.ConfigGet("extensions.worktreeConfig")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	}
+	return false, nil
+}
+
+// configGet returns the value of key in the given scope, or an empty string if it is unset
+func configGet(scope Scope, key string) (string, error) {
+	if scope == ScopeWorktree {
+		// Nothing can be set for the worktree without per-worktree configuration
+		if enabled, err := worktreeConfig(); err != nil || !enabled {
+			return "", err
+		}
+	}
+	return git.ConfigGet(key, scopeOptions(scope)...)
+}
+
+// checkWritable returns an error if git would refuse to write the configuration of scope
+func checkWritable(scope Scope) error {
+	if scope != ScopeWorktree {
+		return nil
+	}
+	enabled, err := worktreeConfig()
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return errNoWorktreeConfig
+	}
+	return nil
+}
+
+// configSet sets key to value in the given scope
+func configSet(scope Scope, key, value string) error {
+	if err := checkWritable(scope); err != nil {
+		return err
+	}
+	return git.ConfigSet(key, value, scopeOptions(scope)...)
+}
+
+// configUnset removes key from the given scope, ignoring keys that are not set
+func configUnset(scope Scope, key string) error {
+	if scope == ScopeWorktree {
+		if enabled, err := worktreeConfig(); err != nil || !enabled {
+			return err
+		}
+	}
+	return git.ConfigUnset(key, scopeOptions(scope)...)
+}
@@ -0,0 +1,80 @@
+package gittemplate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/philippeckel/pair/internal/git"
+)
+
+// Scope selects which git configuration file holds commit.template
+type Scope string
+
+const (
+	// ScopeGlobal writes one template shared by every repository of the user
+	ScopeGlobal Scope = "global"
+	// ScopeLocal writes a template for the current repository only
+	ScopeLocal Scope = "local"
+	// ScopeWorktree writes a template for the current worktree only
+	ScopeWorktree Scope = "worktree"
+)
+
+// Scopes lists all supported scopes
+var Scopes = []Scope{ScopeGlobal, ScopeLocal, ScopeWorktree}
+
+// scopeSystem is the configuration of all users, which pair reads but never writes
+const scopeSystem Scope = "system"
+
+// lower returns the scopes git reads before s, lowest precedence first
+func (s Scope) lower() []Scope {
+	precedence := []Scope{scopeSystem, ScopeGlobal, ScopeLocal, ScopeWorktree}
+	for i, scope := range precedence {
+		if scope == s {
+			return precedence[:i]
+		}
+	}
+	return nil
+}
+
+// ParseScope converts a user supplied value into a Scope
+func ParseScope(value string) (Scope, error) {
+	if value == "" {
+		return ScopeGlobal, nil
+	}
+
+	for _, scope := range Scopes {
+		if strings.EqualFold(value, string(scope)) {
+			return scope, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown scope '%s' (supported: global, local, worktree)", value)
+}
+
+// flag returns the git config option selecting this scope
+func (s Scope) flag() string {
+	return "--" + string(s)
+}
+
+// GitDir returns the absolute path of the directory holding the template of the
+// local and worktree scopes. Local templates live in the common git directory so
+// that they are shared by all worktrees, worktree templates in the worktree
+// specific one.
+func (s Scope) GitDir() (string, error) {
+	option := "--git-common-dir"
+	if s == ScopeWorktree {
+		option = "--git-dir"
+	}
+
+	output, err := git.Run("rev-parse", option)
+	if err != nil {
+		return "", fmt.Errorf("%s scope requires a git repository: %w", s, err)
+	}
+
+	dir, err := filepath.Abs(strings.TrimSpace(output))
+	if err != nil {
+		return "", fmt.Errorf("could not resolve git directory: %w", err)
+	}
+	return dir, nil
+}
@@ -0,0 +1,71 @@
+package gittemplate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// expiresPrefix marks the comment line recording when a time-boxed session ends
+const expiresPrefix = "# Expires:"
+
+// ReadExpiry returns when the pairing session recorded in the template ends.
+// The zero time means the session does not expire.
+func ReadExpiry(templatePath string) (time.Time, error) {
+	if templatePath == "" {
+		return time.Time{}, nil
+	}
+
+	lines, err := readBlock(templatePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, expiresPrefix) {
+			continue
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(line, expiresPrefix))
+		expires, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid session expiry '%s' in %s: %w", value, templatePath, err)
+		}
+		return expires, nil
+	}
+
+	return time.Time{}, nil
+}
+
+// SessionEnded returns when the session in the template of the given scope
+// ended, or the zero time if it has not ended or does not expire
+func SessionEnded(scope Scope) (time.Time, error) {
+	templatePath, err := GetCurrentTemplate(scope)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	expires, err := ReadExpiry(templatePath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if expires.IsZero() || time.Now().Before(expires) {
+		return time.Time{}, nil
+	}
+	return expires, nil
+}
+
+// ExpireSession clears the template of the given scope if its session has ended.
+// It returns when the cleared session expired, or the zero time if nothing was cleared.
+func ExpireSession(scope Scope) (time.Time, error) {
+	expires, err := SessionEnded(scope)
+	if err != nil || expires.IsZero() {
+		return time.Time{}, err
+	}
+
+	if err := ClearTemplate(scope); err != nil {
+		return time.Time{}, err
+	}
+	return expires, nil
+}
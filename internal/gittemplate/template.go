@@ -3,29 +3,80 @@ package gittemplate
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/identity"
 	"github.com/philippeckel/pair/internal/models"
 )
 
-// GetCurrentTemplate returns the path to the current git commit template
-func GetCurrentTemplate() (string, error) {
-	cmd := exec.Command("git", "config", "--get", "commit.template")
-	output, err := cmd.Output()
+const (
+	// templateFileName is the name of the template written into the git directory
+	// for the local and worktree scopes
+	templateFileName = "pair_commit_template"
+
+	// baseTemplateKey stores the commit template that was configured before pair
+	// took over, so that it can be kept as a base and restored on clear
+	baseTemplateKey = "pair.baseTemplate"
+
+	// blockStart and blockEnd delimit the co-author block managed by pair
+	blockStart = "# Co-authors:"
+	blockEnd   = "# End of co-authors"
+)
+
+// GetCurrentTemplate returns the path to the git commit template set in the given scope
+func GetCurrentTemplate(scope Scope) (string, error) {
+	return configGet(scope, "commit.template")
+}
+
+// GetEffectiveTemplate returns the path to the commit template git uses in the
+// current directory together with the scope it was configured in. The scope is
+// empty if the template is not set in a scope managed by pair, e.g. system.
+func GetEffectiveTemplate() (Scope, string, error) {
+	output, err := git.Run("config", "--show-scope", "--get", "commit.template")
 	if err != nil {
-		// It's ok if the template doesn't exist yet
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			return "", nil
+		// Exit code 1 means the template is not set
+		if git.ExitCode(err) == 1 {
+			return "", "", nil
 		}
-		return "", err
+		return "", "", err
 	}
-	return strings.TrimSpace(string(output)), nil
+
+	origin, templatePath, _ := strings.Cut(strings.TrimSpace(output), "\t")
+	for _, scope := range Scopes {
+		if origin == string(scope) {
+			return scope, templatePath, nil
+		}
+	}
+	return "", templatePath, nil
 }
 
-// getTemplatePath returns a persistent path for the git template
-func getTemplatePath() (string, error) {
+// GetBaseTemplate returns the path of the user's own template that pair builds upon
+// in the given scope, or an empty string if there is none
+func GetBaseTemplate(scope Scope) (string, error) {
+	return configGet(scope, baseTemplateKey)
+}
+
+// getTemplatePath returns a persistent path for the git template of the given scope
+func getTemplatePath(scope Scope) (string, error) {
+	if scope != ScopeGlobal {
+		gitDir, err := scope.GitDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(gitDir, templateFileName), nil
+	}
+
+	if templatePath := config.GetTemplatePath(); templatePath != "" {
+		if err := os.MkdirAll(filepath.Dir(templatePath), 0755); err != nil {
+			return "", fmt.Errorf("could not create template directory: %w", err)
+		}
+		return templatePath, nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("could not get home directory: %w", err)
@@ -40,46 +91,213 @@ func getTemplatePath() (string, error) {
 	return filepath.Join(configDir, "git_commit_template"), nil
 }
 
-// UpdateTemplate writes a new git template with the given co-authors
-func UpdateTemplate(activeCoAuthors []models.CoAuthor) error {
-	// Get a persistent path for the template
-	templatePath, err := getTemplatePath()
+// expandPath resolves a leading "~/" the same way git does for commit.template
+func expandPath(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, path[2:])
+}
+
+// IsPairTemplate reports whether the template at path was written by pair
+func IsPairTemplate(path string) bool {
+	data, err := os.ReadFile(expandPath(path))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == blockStart {
+			return true
+		}
+	}
+	return false
+}
+
+// adoptBaseTemplate remembers a commit template that was configured by the user
+// before pair wrote its own one
+func adoptBaseTemplate(scope Scope, templatePath string) error {
+	current, err := GetCurrentTemplate(scope)
 	if err != nil {
 		return err
 	}
 
+	if current == "" || expandPath(current) == templatePath || IsPairTemplate(current) {
+		return nil
+	}
+
+	return configSet(scope, baseTemplateKey, current)
+}
+
+// inheritedTemplate returns the user's own template that git would use if scope
+// had none, e.g. the team's global template for a local pair template. It is
+// read when the template is written instead of being adopted, so clearing the
+// scope leaves the template to the other scopes again.
+func inheritedTemplate(scope Scope) (string, error) {
+	lower := scope.lower()
+	for i := len(lower) - 1; i >= 0; i-- {
+		current, err := configGet(lower[i], "commit.template")
+		if err != nil {
+			return "", err
+		}
+		if current == "" {
+			continue
+		}
+		if IsPairTemplate(current) {
+			return GetBaseTemplate(lower[i])
+		}
+		return current, nil
+	}
+	return "", nil
+}
+
+// renderTemplate builds the template content from the base template, the co-authors
+// and the end of the session, if it is time-boxed
+func renderTemplate(base string, activeCoAuthors []models.CoAuthor, expires time.Time) string {
 	var content strings.Builder
+	if strings.TrimSpace(base) != "" {
+		content.WriteString(strings.TrimRight(base, "\n"))
+	}
 	content.WriteString("\n\n") // Leave space for commit message
-	content.WriteString("# Co-authors:\n")
+	content.WriteString(blockStart + "\n")
+	if !expires.IsZero() {
+		content.WriteString(fmt.Sprintf("%s %s\n", expiresPrefix, expires.Format(time.RFC3339)))
+	}
+
+	for _, author := range activeCoAuthors {
+		content.WriteString(author.Trailer() + "\n")
+	}
+
+	content.WriteString(blockEnd + "\n")
+	return content.String()
+}
+
+// CreditedEmails sets the email of every co-author to the one their roster entry
+// selects for the current repository. Co-authors without further emails, such
+// as the ones read back from a template, are looked up in roster, so their email
+// follows the repository as well.
+func CreditedEmails(activeCoAuthors, roster []models.CoAuthor) []models.CoAuthor {
+	matcher := identity.NewMatcher(roster)
+
+	var repo *models.Repository
+	homeDir, _ := os.UserHomeDir()
 
+	credited := make([]models.CoAuthor, 0, len(activeCoAuthors))
 	for _, author := range activeCoAuthors {
-		content.WriteString(fmt.Sprintf("Co-authored-by: %s <%s>\n", author.Name, author.Email))
+		entry := author
+		if len(entry.Emails) == 0 && len(roster) > 0 {
+			if rosterEntry, ok := matcher.Lookup(author.Email); ok {
+				entry = rosterEntry
+			}
+		}
+
+		if len(entry.Emails) > 0 {
+			// Only look at the repository if there is something to select
+			if repo == nil {
+				repo = &models.Repository{Path: git.TopLevel(), Hosts: git.RemoteHosts()}
+			}
+			author.Email = entry.EmailFor(*repo, homeDir)
+		}
+		credited = append(credited, author)
+	}
+	return credited
+}
+
+// UpdateTemplate writes a new git template with the given co-authors and
+// points commit.template of the given scope to it. A template the user had
+// configured before is kept as the body above the co-author block, and the
+// end of a time-boxed session is carried over.
+func UpdateTemplate(scope Scope, activeCoAuthors []models.CoAuthor) error {
+	currentPath, err := GetCurrentTemplate(scope)
+	if err != nil {
+		return err
+	}
+
+	var expires time.Time
+	if currentPath != "" && IsPairTemplate(currentPath) {
+		if expires, err = ReadExpiry(currentPath); err != nil {
+			return err
+		}
+	}
+
+	return UpdateTemplateUntil(scope, activeCoAuthors, expires)
+}
+
+// UpdateTemplateUntil works like UpdateTemplate but starts a session that ends at
+// expires. The zero time starts a session without an end.
+func UpdateTemplateUntil(scope Scope, activeCoAuthors []models.CoAuthor, expires time.Time) error {
+	// Fail before anything is written
+	if err := checkWritable(scope); err != nil {
+		return err
+	}
+
+	// Get a persistent path for the template
+	templatePath, err := getTemplatePath(scope)
+	if err != nil {
+		return err
+	}
+
+	if err := adoptBaseTemplate(scope, templatePath); err != nil {
+		return err
+	}
+
+	basePath, err := GetBaseTemplate(scope)
+	if err != nil {
+		return err
+	}
+	if basePath == "" {
+		if basePath, err = inheritedTemplate(scope); err != nil {
+			return err
+		}
 	}
 
-	if err := os.WriteFile(templatePath, []byte(content.String()), 0644); err != nil {
+	var base string
+	if basePath != "" {
+		data, err := os.ReadFile(expandPath(basePath))
+		if err != nil {
+			return fmt.Errorf("could not read base commit template %s: %w", basePath, err)
+		}
+		base = string(data)
+	}
+
+	// The global template is shared by all repositories, so it keeps the primary
+	// emails and the hook selects the one for the repository at commit time
+	if scope != ScopeGlobal {
+		activeCoAuthors = CreditedEmails(activeCoAuthors, nil)
+	}
+
+	content := renderTemplate(base, activeCoAuthors, expires)
+	if err := os.WriteFile(templatePath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write template file: %w", err)
 	}
 
 	// Set the commit.template configuration
-	cmd := exec.Command("git", "config", "--global", "commit.template", templatePath)
-	if err := cmd.Run(); err != nil {
+	if err := configSet(scope, "commit.template", templatePath); err != nil {
 		return fmt.Errorf("failed to set git commit template: %w", err)
 	}
 
 	return nil
 }
 
-// ClearTemplate removes the commit template configuration from git
-func ClearTemplate() error {
-	// Unset the commit.template configuration
-	cmd := exec.Command("git", "config", "--global", "--unset", "commit.template")
-	if err := cmd.Run(); err != nil {
-		// Exit code 5 means the section or key doesn't exist, which is fine
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 5 {
-			return nil
-		}
-		return fmt.Errorf("failed to unset git commit template: %w", err)
+// ClearTemplate removes pair's commit template from the given scope, restoring the
+// template that was configured before pair took over if there was one
+func ClearTemplate(scope Scope) error {
+	basePath, err := GetBaseTemplate(scope)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	if basePath == "" {
+		// Unset the commit.template configuration
+		return configUnset(scope, "commit.template")
+	}
+
+	if err := configSet(scope, "commit.template", basePath); err != nil {
+		return fmt.Errorf("failed to restore git commit template: %w", err)
+	}
+
+	return configUnset(scope, baseTemplateKey)
 }
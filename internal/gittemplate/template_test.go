@@ -0,0 +1,220 @@
+package gittemplate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	jane = models.CoAuthor{Alias: "jane", Name: "Jane Doe", Email: "jane@company.example.com", Emails: []models.Email{
+		{Address: "jane@users.noreply.github.com", Hosts: []string{"github.com"}},
+	}}
+	john = models.CoAuthor{Alias: "john", Name: "John Doe", Email: "john@example.com", TrailerKey: "Reviewed-by"}
+)
+
+// setupFake points all git calls to an in-memory fake of a repository with
+// a GitHub remote and keeps the home directory out of the way
+func setupFake(t *testing.T) *git.Fake {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	fake := git.NewFake()
+	fake.GitDir = filepath.Join(dir, "repo", ".git")
+	require.NoError(t, os.MkdirAll(fake.GitDir, 0755))
+	fake.Handlers["remote"] = func(git.Command) (string, error) {
+		return "origin\tgit@github.com:team/project.git (fetch)\n", nil
+	}
+
+	previous := git.Default
+	git.Default = fake
+	t.Cleanup(func() { git.Default = previous })
+	return fake
+}
+
+// emails returns the emails of the co-authors
+func emails(authors []models.CoAuthor) []string {
+	var result []string
+	for _, author := range authors {
+		result = append(result, author.Email)
+	}
+	return result
+}
+
+// active returns the emails of the active co-authors of the scope
+func active(t *testing.T, scope Scope) []string {
+	t.Helper()
+	templatePath, err := GetCurrentTemplate(scope)
+	require.NoError(t, err)
+	authors, err := ParseActiveCoAuthors(templatePath, nil)
+	require.NoError(t, err)
+	return emails(authors)
+}
+
+func TestUpdateTemplateKeepsBaseTemplate(t *testing.T) {
+	fake := setupFake(t)
+	base := filepath.Join(t.TempDir(), "base_template")
+	require.NoError(t, os.WriteFile(base, []byte("Summary\n\n# Explain why\n"), 0644))
+	fake.Config["global"]["commit.template"] = base
+
+	require.NoError(t, UpdateTemplate(ScopeGlobal, []models.CoAuthor{jane, john}))
+	templatePath, err := GetCurrentTemplate(ScopeGlobal)
+	require.NoError(t, err)
+	assert.NotEqual(t, base, templatePath)
+	assert.True(t, IsPairTemplate(templatePath))
+	assert.False(t, IsPairTemplate(base))
+
+	data, err := os.ReadFile(templatePath)
+	require.NoError(t, err)
+	assert.Equal(t, "Summary\n\n# Explain why\n\n"+blockStart+"\n"+
+		"Co-authored-by: Jane Doe <jane@company.example.com>\n"+
+		"Reviewed-by: John Doe <john@example.com>\n"+
+		blockEnd+"\n", string(data))
+
+	// Updating again keeps the base template instead of adopting pair's own one
+	require.NoError(t, UpdateTemplate(ScopeGlobal, []models.CoAuthor{jane}))
+	current, err := GetBaseTemplate(ScopeGlobal)
+	require.NoError(t, err)
+	assert.Equal(t, base, current)
+
+	require.NoError(t, ClearTemplate(ScopeGlobal))
+	assert.Equal(t, base, fake.Config["global"]["commit.template"])
+	current, err = GetBaseTemplate(ScopeGlobal)
+	require.NoError(t, err)
+	assert.Empty(t, current)
+
+	// The base template itself has no active co-authors
+	assert.Empty(t, active(t, ScopeGlobal))
+}
+
+func TestLocalTemplateKeepsGlobalBaseTemplate(t *testing.T) {
+	fake := setupFake(t)
+	base := filepath.Join(t.TempDir(), "team_template")
+	require.NoError(t, os.WriteFile(base, []byte("Summary\n"), 0644))
+	fake.Config["global"]["commit.template"] = base
+
+	require.NoError(t, UpdateTemplate(ScopeLocal, []models.CoAuthor{john}))
+	templatePath, err := GetCurrentTemplate(ScopeLocal)
+	require.NoError(t, err)
+	data, err := os.ReadFile(templatePath)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "Summary\n\n"+blockStart+"\n"), string(data))
+
+	// Also below a global pair template, which builds upon the same base
+	require.NoError(t, UpdateTemplate(ScopeGlobal, []models.CoAuthor{jane}))
+	require.NoError(t, UpdateTemplate(ScopeLocal, []models.CoAuthor{john}))
+	data, err = os.ReadFile(templatePath)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "Summary\n\n"+blockStart+"\n"), string(data))
+
+	// Clearing leaves the template to the global scope again
+	require.NoError(t, ClearTemplate(ScopeLocal))
+	assert.NotContains(t, fake.Config["local"], "commit.template")
+}
+
+func TestSessionExpiry(t *testing.T) {
+	setupFake(t)
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	require.NoError(t, UpdateTemplateUntil(ScopeGlobal, []models.CoAuthor{jane}, expires))
+	require.NoError(t, UpdateTemplate(ScopeGlobal, []models.CoAuthor{jane, john}))
+
+	templatePath, err := GetCurrentTemplate(ScopeGlobal)
+	require.NoError(t, err)
+	got, err := ReadExpiry(templatePath)
+	require.NoError(t, err)
+	assert.True(t, expires.Equal(got), "updating keeps the end of the session")
+
+	expired, err := ExpireSession(ScopeGlobal)
+	require.NoError(t, err)
+	assert.True(t, expired.IsZero())
+
+	require.NoError(t, UpdateTemplateUntil(ScopeGlobal, []models.CoAuthor{jane}, time.Now().Add(-time.Minute)))
+	expired, err = ExpireSession(ScopeGlobal)
+	require.NoError(t, err)
+	assert.False(t, expired.IsZero())
+	assert.Empty(t, active(t, ScopeGlobal))
+}
+
+func TestEmailsAreOnlySelectedForRepositoryScopes(t *testing.T) {
+	setupFake(t)
+
+	// The global template is shared by all repositories
+	require.NoError(t, UpdateTemplate(ScopeGlobal, []models.CoAuthor{jane, john}))
+	assert.Equal(t, []string{"jane@company.example.com", "john@example.com"}, active(t, ScopeGlobal))
+
+	require.NoError(t, UpdateTemplate(ScopeLocal, []models.CoAuthor{jane, john}))
+	assert.Equal(t, []string{"jane@users.noreply.github.com", "john@example.com"}, active(t, ScopeLocal))
+}
+
+func TestCreditedEmails(t *testing.T) {
+	fake := setupFake(t)
+	roster := []models.CoAuthor{jane, john}
+
+	// Co-authors read back from a template are looked up in the roster
+	template := []models.CoAuthor{{Name: "Jane Doe", Email: "jane@company.example.com"}, john}
+	assert.Equal(t, []string{"jane@users.noreply.github.com", "john@example.com"}, emails(CreditedEmails(template, roster)))
+	assert.Equal(t, []string{"jane@company.example.com", "john@example.com"}, emails(CreditedEmails(template, nil)))
+
+	// Outside of any repository the primary email is credited
+	fake.GitDir = ""
+	fake.Handlers["remote"] = func(cmd git.Command) (string, error) {
+		return "", &git.ExitError{Args: cmd.Args, Code: 128}
+	}
+	assert.Equal(t, []string{"jane@company.example.com", "john@example.com"}, emails(CreditedEmails(template, roster)))
+}
+
+func TestParseActiveCoAuthorsIgnoresOtherTemplates(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "template")
+	require.NoError(t, os.WriteFile(other, []byte("Co-authored-by: Jane Doe <jane@example.com>\n"), 0644))
+
+	authors, err := ParseActiveCoAuthors(other, nil)
+	require.NoError(t, err)
+	assert.Empty(t, authors)
+
+	authors, err = ParseActiveCoAuthors("", nil)
+	require.NoError(t, err)
+	assert.Empty(t, authors)
+}
+
+func TestParseTrailersUsesKnownSpelling(t *testing.T) {
+	lines := []string{
+		"co-authored-by: Jane Doe <jane@example.com>",
+		"ACKED-BY: John Doe <john@example.com>",
+		"Not a trailer",
+	}
+
+	authors := ParseTrailers(lines, []string{"Co-authored-by", "Acked-by"})
+	require.Len(t, authors, 2)
+	assert.Equal(t, "Co-authored-by", authors[0].TrailerKey)
+	assert.Equal(t, "Acked-by", authors[1].TrailerKey)
+
+	// Unknown keys are kept as written
+	authors = ParseTrailers(lines, nil)
+	assert.Equal(t, "ACKED-BY", authors[1].TrailerKey)
+	assert.True(t, authors[0].IsCoAuthor())
+}
+
+func TestWorktreeScopeRequiresWorktreeConfig(t *testing.T) {
+	fake := setupFake(t)
+
+	// Nothing is written where git would refuse it, and there is nothing to read
+	assert.ErrorContains(t, UpdateTemplate(ScopeWorktree, []models.CoAuthor{jane}), "git config extensions.worktreeConfig true")
+	assert.NoFileExists(t, filepath.Join(fake.GitDir, templateFileName))
+	assert.Empty(t, active(t, ScopeWorktree))
+	require.NoError(t, ClearTemplate(ScopeWorktree))
+
+	fake.Config["local"]["extensions.worktreeconfig"] = "true"
+	require.NoError(t, UpdateTemplate(ScopeWorktree, []models.CoAuthor{jane}))
+	assert.Equal(t, []string{"jane@users.noreply.github.com"}, active(t, ScopeWorktree))
+	assert.Empty(t, active(t, ScopeLocal))
+}
@@ -0,0 +1,124 @@
+package identity
+
+import (
+	"strings"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+)
+
+// Matcher tells whether two addresses belong to the same person. Addresses are
+// compared case-insensitively after mapping them through the .mailmap of the
+// repository (including mailmap.file), and all addresses of a roster entry
+// belong to the person of that entry.
+type Matcher struct {
+	roster []models.CoAuthor
+	owners map[string]int // Canonical address to index into roster
+}
+
+// mailmapCache holds the canonical address of every address looked up so far.
+// It is only valid for the runner it was filled with.
+var mailmapCache = struct {
+	runner    git.Runner
+	canonical map[string]string
+}{}
+
+// NewMatcher returns a matcher for the roster
+func NewMatcher(roster []models.CoAuthor) *Matcher {
+	var emails []string
+	for _, author := range roster {
+		emails = append(emails, author.AllEmails()...)
+	}
+	lookupMailmap(emails)
+
+	// Keep a copy, the roster may be changed while the matcher is in use
+	m := &Matcher{roster: append([]models.CoAuthor{}, roster...), owners: map[string]int{}}
+	for i, author := range roster {
+		for _, email := range author.AllEmails() {
+			// The first entry listing an address owns it
+			if _, taken := m.owners[canonical(email)]; !taken {
+				m.owners[canonical(email)] = i
+			}
+		}
+	}
+	return m
+}
+
+// Preload looks up the canonical form of many addresses at once, which saves a
+// git call per address when they are compared later
+func (m *Matcher) Preload(emails []string) {
+	lookupMailmap(emails)
+}
+
+// Key returns the identity of the address. All addresses of one person have the
+// same key, which is the lower-cased primary email of the roster entry if there
+// is one and the lower-cased canonical address otherwise.
+func (m *Matcher) Key(email string) string {
+	if author, ok := m.Lookup(email); ok {
+		return strings.ToLower(author.Email)
+	}
+	return canonical(email)
+}
+
+// Same reports whether two addresses belong to the same person
+func (m *Matcher) Same(emailA, emailB string) bool {
+	return m.Key(emailA) == m.Key(emailB)
+}
+
+// Lookup returns the roster entry of the person with the address
+func (m *Matcher) Lookup(email string) (models.CoAuthor, bool) {
+	index, ok := m.owners[canonical(email)]
+	if !ok {
+		return models.CoAuthor{}, false
+	}
+	return m.roster[index], true
+}
+
+// Contains reports whether the person with the address is one of the authors
+func (m *Matcher) Contains(authors []models.CoAuthor, email string) bool {
+	for _, author := range authors {
+		if m.Same(author.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonical returns the lower-cased canonical form of the address
+func canonical(email string) string {
+	key := strings.ToLower(strings.TrimSpace(email))
+	lookupMailmap([]string{key})
+	return mailmapCache.canonical[key]
+}
+
+// lookupMailmap maps the addresses that are not cached yet through .mailmap with
+// a single git call. If the mailmap cannot be read, for example outside of a
+// repository, the addresses are their own canonical form.
+func lookupMailmap(emails []string) {
+	if mailmapCache.runner != git.Default || mailmapCache.canonical == nil {
+		mailmapCache.runner = git.Default
+		mailmapCache.canonical = map[string]string{}
+	}
+
+	var keys, contacts []string
+	for _, email := range emails {
+		key := strings.ToLower(strings.TrimSpace(email))
+		if _, cached := mailmapCache.canonical[key]; cached {
+			continue
+		}
+		mailmapCache.canonical[key] = key
+		keys = append(keys, key)
+		contacts = append(contacts, "<"+key+">")
+	}
+
+	mapped, err := git.CheckMailmap(contacts)
+	if err != nil {
+		return
+	}
+
+	for i, contact := range mapped {
+		if _, email, found := strings.Cut(contact, "<"); found {
+			mailmapCache.canonical[keys[i]] = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(email), ">"))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcher(t *testing.T) {
+	fake := git.NewFake()
+	fake.Mailmap["jdoe@old.example.com"] = "Jane Doe <jane@example.com>"
+	fake.Mailmap["sam@laptop.local"] = "<sam@example.com>"
+	previous := git.Default
+	git.Default = fake
+	t.Cleanup(func() { git.Default = previous })
+
+	roster := []models.CoAuthor{
+		{Alias: "jane", Name: "Jane Doe", Email: "jane@example.com"},
+		{Alias: "john", Name: "John Doe", Email: "john@example.com", Emails: []models.Email{{Address: "john@personal.example.org"}}},
+	}
+	m := NewMatcher(roster)
+
+	tests := []struct {
+		name   string
+		a, b   string
+		same   bool
+		either string // Alias of the roster entry, if any
+	}{
+		{"identical", "jane@example.com", "jane@example.com", true, "jane"},
+		{"case", "Jane@Example.com", "jane@example.com", true, "jane"},
+		{"mailmap", "jdoe@old.example.com", "jane@example.com", true, "jane"},
+		{"alternate", "JOHN@personal.example.org", "john@example.com", true, "john"},
+		{"mailmap outside roster", "sam@laptop.local", "sam@example.com", true, ""},
+		{"different", "jane@example.com", "john@example.com", false, "jane"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.same, m.Same(tt.a, tt.b))
+
+			author, ok := m.Lookup(tt.a)
+			assert.Equal(t, tt.either != "", ok)
+			assert.Equal(t, tt.either, author.Alias)
+		})
+	}
+
+	assert.True(t, m.Contains(roster, "jdoe@old.example.com"))
+	assert.False(t, m.Contains(roster, "sam@example.com"))
+}
@@ -0,0 +1,204 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/philippeckel/pair/internal/config"
+	"github.com/philippeckel/pair/internal/models"
+)
+
+// MaxEntries is the number of changes kept, older ones are dropped
+const MaxEntries = 100
+
+// Member is an active co-author as recorded in the journal
+type Member struct {
+	Alias   string `json:"alias,omitempty"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Trailer string `json:"trailer,omitempty"` // Empty for Co-authored-by
+}
+
+// NewMember records a co-author
+func NewMember(author models.CoAuthor) Member {
+	return Member{Alias: author.Alias, Name: author.Name, Email: author.Email, Trailer: author.TrailerKey}
+}
+
+// CoAuthor converts the member back into a co-author
+func (m Member) CoAuthor() models.CoAuthor {
+	return models.CoAuthor{Alias: m.Alias, Name: m.Name, Email: m.Email, TrailerKey: m.Trailer}
+}
+
+// Snapshot is the set of active co-authors of a scope at one point in time
+type Snapshot struct {
+	CoAuthors []Member   `json:"coauthors"`
+	Expires   *time.Time `json:"expires,omitempty"` // End of a time-boxed session
+}
+
+// Equal reports whether both snapshots credit the same co-authors in the same
+// order until the same time
+func (s Snapshot) Equal(other Snapshot) bool {
+	if len(s.CoAuthors) != len(other.CoAuthors) || (s.Expires == nil) != (other.Expires == nil) {
+		return false
+	}
+	if s.Expires != nil && !s.Expires.Equal(*other.Expires) {
+		return false
+	}
+	for i := range s.CoAuthors {
+		if s.CoAuthors[i] != other.CoAuthors[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Entry is a change of the active co-authors of one scope
+type Entry struct {
+	ID      int       `json:"id"` // Increasing number, shown by pair history
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"` // Command line that made the change
+	Scope   string    `json:"scope"`
+	// Repository is the git directory of the local and worktree scopes, empty for global
+	Repository string   `json:"repository,omitempty"`
+	Before     Snapshot `json:"before"`
+	After      Snapshot `json:"after"`
+	Undone     bool     `json:"undone,omitempty"` // Reverted by pair undo, can be redone
+}
+
+// Journal records the changes of the active co-authors of all scopes, oldest
+// first. Undo and redo work on the entries of one scope and repository at a
+// time, so that undoing in one repository never touches another one.
+type Journal struct {
+	Entries []Entry `json:"entries"`
+}
+
+// journalPath returns the file the journal is persisted in
+func journalPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "pair", "history.json"), nil
+}
+
+// Load returns the journal, which is empty if nothing was recorded yet
+func Load() (*Journal, error) {
+	path, err := journalPath()
+	if err != nil {
+		return nil, err
+	}
+	return load(path)
+}
+
+// Update loads the journal, lets change modify it and saves it. The directory
+// of the journal stays locked meanwhile, so that commands running at the same
+// time, such as the commit hook rotating a mob, do not drop each other's changes.
+// Nothing is saved if change fails.
+func Update(change func(*Journal) error) error {
+	path, err := journalPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+
+	unlock, err := config.LockDir(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	journal, err := load(path)
+	if err != nil {
+		return err
+	}
+	if err := change(journal); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding history: %w", err)
+	}
+	if err := config.WriteFileAtomic(path, data); err != nil {
+		return fmt.Errorf("error writing history: %w", err)
+	}
+	return nil
+}
+
+// load reads the journal from path
+func load(path string) (*Journal, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Journal{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read history: %w", err)
+	}
+
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("could not parse history %s: %w", path, err)
+	}
+	return &journal, nil
+}
+
+// Record appends a change and returns it with its ID. Undone changes of the
+// same scope and repository can no longer be redone and are dropped, and only
+// the last MaxEntries changes are kept.
+func (j *Journal) Record(entry Entry) Entry {
+	entry.ID = 1
+	if len(j.Entries) > 0 {
+		entry.ID = j.Entries[len(j.Entries)-1].ID + 1
+	}
+	entry.Undone = false
+
+	var kept []Entry
+	for _, existing := range j.Entries {
+		if !(existing.Undone && existing.sameTarget(entry.Scope, entry.Repository)) {
+			kept = append(kept, existing)
+		}
+	}
+	j.Entries = append(kept, entry)
+
+	if len(j.Entries) > MaxEntries {
+		j.Entries = j.Entries[len(j.Entries)-MaxEntries:]
+	}
+	return entry
+}
+
+// LastDone returns the most recent change of the scope and repository that
+// has not been undone, or nil if there is nothing to undo
+func (j *Journal) LastDone(scope, repository string) *Entry {
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		entry := &j.Entries[i]
+		if entry.sameTarget(scope, repository) && !entry.Undone {
+			return entry
+		}
+	}
+	return nil
+}
+
+// NextUndone returns the oldest undone change of the scope and repository,
+// the one to redo next, or nil if there is nothing to redo. Undone changes
+// always follow the ones that are still in effect.
+func (j *Journal) NextUndone(scope, repository string) *Entry {
+	for i := range j.Entries {
+		entry := &j.Entries[i]
+		if entry.sameTarget(scope, repository) && entry.Undone {
+			return entry
+		}
+	}
+	return nil
+}
+
+// sameTarget reports whether the entry changed the given scope and repository
+func (e *Entry) sameTarget(scope, repository string) bool {
+	return e.Scope == scope && e.Repository == repository
+}
@@ -0,0 +1,117 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func snapshot(emails ...string) Snapshot {
+	s := Snapshot{CoAuthors: []Member{}}
+	for _, email := range emails {
+		s.CoAuthors = append(s.CoAuthors, Member{Name: email, Email: email})
+	}
+	return s
+}
+
+func TestUndoRedo(t *testing.T) {
+	j := &Journal{}
+	add := j.Record(Entry{Command: "pair add jane", Scope: "global", Before: snapshot(), After: snapshot("jane")})
+	j.Record(Entry{Command: "pair add john", Scope: "local", Repository: "/src/app/.git", Before: snapshot(), After: snapshot("john")})
+	clear := j.Record(Entry{Command: "pair clear", Scope: "global", Before: snapshot("jane"), After: snapshot()})
+	assert.Equal(t, []int{1, 3}, []int{add.ID, clear.ID})
+
+	// Undo walks back the changes of one scope, newest first
+	last := j.LastDone("global", "")
+	require.NotNil(t, last)
+	assert.Equal(t, "pair clear", last.Command)
+	last.Undone = true
+	assert.Equal(t, "pair add jane", j.LastDone("global", "").Command)
+	assert.Equal(t, "pair add john", j.LastDone("local", "/src/app/.git").Command)
+	assert.Nil(t, j.LastDone("local", "/src/other/.git"))
+
+	j.LastDone("global", "").Undone = true
+	assert.Nil(t, j.LastDone("global", ""))
+
+	// Redo replays them oldest first
+	assert.Equal(t, "pair add jane", j.NextUndone("global", "").Command)
+	j.NextUndone("global", "").Undone = false
+	assert.Equal(t, "pair clear", j.NextUndone("global", "").Command)
+	assert.Nil(t, j.NextUndone("local", "/src/app/.git"))
+
+	// A new change drops what could be redone in its scope only
+	j.Entries[1].Undone = true
+	j.Record(Entry{Command: "pair add sam", Scope: "global", Before: snapshot("jane"), After: snapshot("jane", "sam")})
+	assert.Nil(t, j.NextUndone("global", ""))
+	assert.NotNil(t, j.NextUndone("local", "/src/app/.git"))
+
+	var ids []int
+	for _, entry := range j.Entries {
+		ids = append(ids, entry.ID)
+	}
+	assert.Equal(t, []int{1, 2, 4}, ids)
+}
+
+func TestRecordKeepsLastEntries(t *testing.T) {
+	j := &Journal{}
+	for i := 0; i < MaxEntries+5; i++ {
+		j.Record(Entry{Command: fmt.Sprintf("pair add %d", i), Scope: "global"})
+	}
+
+	assert.Len(t, j.Entries, MaxEntries)
+	assert.Equal(t, 6, j.Entries[0].ID)
+	assert.Equal(t, MaxEntries+5, j.Entries[MaxEntries-1].ID)
+}
+
+func TestUpdateAndLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	j, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, j.Entries)
+
+	expires := time.Date(2025, 3, 14, 18, 0, 0, 0, time.UTC)
+	jane := models.CoAuthor{Alias: "jane", Name: "Jane Doe", Email: "jane@example.com", TrailerKey: "Reviewed-by"}
+	require.NoError(t, Update(func(j *Journal) error {
+		j.Record(Entry{
+			Time:    expires.Add(-time.Hour),
+			Command: "pair add --as reviewed-by jane --until 18:00",
+			Scope:   "global",
+			Before:  snapshot(),
+			After:   Snapshot{CoAuthors: []Member{NewMember(jane)}, Expires: &expires},
+		})
+		return nil
+	}))
+	j.Record(Entry{
+		Time:    expires.Add(-time.Hour),
+		Command: "pair add --as reviewed-by jane --until 18:00",
+		Scope:   "global",
+		Before:  snapshot(),
+		After:   Snapshot{CoAuthors: []Member{NewMember(jane)}, Expires: &expires},
+	})
+
+	loaded, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, j, loaded)
+	assert.Equal(t, jane, loaded.Entries[0].After.CoAuthors[0].CoAuthor())
+	assert.True(t, loaded.Entries[0].After.Equal(j.Entries[0].After))
+	assert.False(t, loaded.Entries[0].After.Equal(loaded.Entries[0].Before))
+}
+
+func TestFailedUpdateIsNotSaved(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	assert.EqualError(t, Update(func(j *Journal) error {
+		j.Record(Entry{Command: "pair clear", Scope: "global"})
+		return errors.New("failed")
+	}), "failed")
+
+	j, err := Load()
+	require.NoError(t, err)
+	assert.Empty(t, j.Entries)
+}
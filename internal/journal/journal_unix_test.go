@@ -0,0 +1,34 @@
+//go:build unix
+
+package journal
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateKeepsConcurrentChanges(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, Update(func(j *Journal) error {
+				j.Record(Entry{Command: fmt.Sprintf("pair add %d", i), Scope: "global"})
+				return nil
+			}))
+		}(i)
+	}
+	wg.Wait()
+
+	j, err := Load()
+	require.NoError(t, err)
+	assert.Len(t, j.Entries, 10)
+
+}
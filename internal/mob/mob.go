@@ -0,0 +1,175 @@
+package mob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/philippeckel/pair/internal/models"
+)
+
+// Member is a participant of the mob
+type Member struct {
+	Alias string `json:"alias"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CoAuthor converts the member into a co-author
+func (m Member) CoAuthor() models.CoAuthor {
+	return models.CoAuthor{Name: m.Name, Email: m.Email, Alias: m.Alias}
+}
+
+// State is a running mob session. The first member is the current driver,
+// the others navigate in the order they will drive next.
+type State struct {
+	Members   []Member  `json:"members"`
+	Interval  string    `json:"interval,omitempty"` // Rotation interval, empty for manual rotation
+	RotatedAt time.Time `json:"rotated_at"`
+	Scope     string    `json:"scope"` // Commit template scope the trailers are written to
+	// Repository is the git directory of the local and worktree scopes, empty for global
+	Repository string `json:"repository,omitempty"`
+}
+
+// statePath returns the file the mob state is persisted in
+func statePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "pair", "mob.json"), nil
+}
+
+// Load returns the running mob session, or nil if there is none
+func Load() (*State, error) {
+	path, err := statePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read mob state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("could not parse mob state %s: %w", path, err)
+	}
+	if len(state.Members) == 0 {
+		return nil, fmt.Errorf("mob state %s has no members", path)
+	}
+	if _, err := state.IntervalDuration(); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Save persists the mob session
+func Save(state *State) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("could not create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding mob state: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing mob state: %w", err)
+	}
+	return nil
+}
+
+// Remove deletes the persisted mob session
+func Remove() error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing mob state: %w", err)
+	}
+	return nil
+}
+
+// IntervalDuration returns the rotation interval, zero for manual rotation
+func (s *State) IntervalDuration() (time.Duration, error) {
+	if s.Interval == "" {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(s.Interval)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mob interval '%s': %w", s.Interval, err)
+	}
+	return interval, nil
+}
+
+// Driver returns the member currently at the keyboard
+func (s *State) Driver() Member {
+	return s.Members[0]
+}
+
+// Navigators returns everyone except the driver as co-authors
+func (s *State) Navigators() []models.CoAuthor {
+	navigators := make([]models.CoAuthor, 0, len(s.Members)-1)
+	for _, member := range s.Members[1:] {
+		navigators = append(navigators, member.CoAuthor())
+	}
+	return navigators
+}
+
+// Rotate hands the keyboard to the next member the given number of times
+func (s *State) Rotate(steps int, now time.Time) {
+	if steps <= 0 {
+		return
+	}
+	shift := steps % len(s.Members)
+	s.Members = append(s.Members[shift:], s.Members[:shift]...)
+	s.RotatedAt = now
+}
+
+// DueRotations returns how many rotation intervals have elapsed since the last rotation
+func (s *State) DueRotations(now time.Time) int {
+	interval, err := s.IntervalDuration()
+	if err != nil || interval <= 0 || now.Before(s.RotatedAt) {
+		return 0
+	}
+	return int(now.Sub(s.RotatedAt) / interval)
+}
+
+// AdvanceTimer applies the rotations that are due and returns how many there were.
+// RotatedAt advances by whole intervals so no time is lost when catching up.
+func (s *State) AdvanceTimer(now time.Time) int {
+	steps := s.DueRotations(now)
+	if steps == 0 {
+		return 0
+	}
+
+	interval, _ := s.IntervalDuration()
+	s.Rotate(steps, s.RotatedAt.Add(time.Duration(steps)*interval))
+	return steps
+}
+
+// NextRotation returns when the timer hands the keyboard to the next member,
+// or the zero time for manual rotation
+func (s *State) NextRotation() time.Time {
+	interval, err := s.IntervalDuration()
+	if err != nil || interval <= 0 {
+		return time.Time{}
+	}
+	return s.RotatedAt.Add(interval)
+}
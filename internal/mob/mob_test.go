@@ -0,0 +1,99 @@
+package mob
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newState(interval string, rotatedAt time.Time) *State {
+	return &State{
+		Members: []Member{
+			{Alias: "alice", Name: "Alice", Email: "alice@example.com"},
+			{Alias: "bob", Name: "Bob", Email: "bob@example.com"},
+			{Alias: "carol", Name: "Carol", Email: "carol@example.com"},
+		},
+		Interval:  interval,
+		RotatedAt: rotatedAt,
+	}
+}
+
+func aliases(state *State) []string {
+	var result []string
+	for _, member := range state.Members {
+		result = append(result, member.Alias)
+	}
+	return result
+}
+
+func TestRotate(t *testing.T) {
+	start := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	state := newState("", start)
+
+	assert.Equal(t, "alice", state.Driver().Alias)
+	assert.Len(t, state.Navigators(), 2)
+
+	state.Rotate(1, start.Add(time.Minute))
+	assert.Equal(t, []string{"bob", "carol", "alice"}, aliases(state))
+	assert.Equal(t, start.Add(time.Minute), state.RotatedAt)
+
+	state.Rotate(4, start)
+	assert.Equal(t, []string{"carol", "alice", "bob"}, aliases(state))
+}
+
+func TestAdvanceTimer(t *testing.T) {
+	start := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		interval      string
+		now           time.Time
+		expectSteps   int
+		expectOrder   []string
+		expectRotated time.Time
+	}{
+		{
+			name:          "Manual rotation",
+			interval:      "",
+			now:           start.Add(time.Hour),
+			expectSteps:   0,
+			expectOrder:   []string{"alice", "bob", "carol"},
+			expectRotated: start,
+		},
+		{
+			name:          "Interval not elapsed",
+			interval:      "10m",
+			now:           start.Add(9 * time.Minute),
+			expectSteps:   0,
+			expectOrder:   []string{"alice", "bob", "carol"},
+			expectRotated: start,
+		},
+		{
+			name:          "One interval elapsed",
+			interval:      "10m",
+			now:           start.Add(12 * time.Minute),
+			expectSteps:   1,
+			expectOrder:   []string{"bob", "carol", "alice"},
+			expectRotated: start.Add(10 * time.Minute),
+		},
+		{
+			name:          "Catch up several intervals",
+			interval:      "10m",
+			now:           start.Add(25 * time.Minute),
+			expectSteps:   2,
+			expectOrder:   []string{"carol", "alice", "bob"},
+			expectRotated: start.Add(20 * time.Minute),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newState(tc.interval, start)
+
+			assert.Equal(t, tc.expectSteps, state.AdvanceTimer(tc.now))
+			assert.Equal(t, tc.expectOrder, aliases(state))
+			assert.Equal(t, tc.expectRotated, state.RotatedAt)
+		})
+	}
+}
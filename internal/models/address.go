@@ -0,0 +1,181 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/philippeckel/pair/internal/trailer"
+)
+
+// FieldError is a problem with one field of a roster entry
+type FieldError struct {
+	Field   string // Dotted path of the field in the entry, e.g. "email" or "emails.1.hosts.0"
+	Err     error
+	Warning bool // The entry works once the field is sanitized, e.g. whitespace in a name
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// atextSpecials are the characters besides letters and digits allowed in an
+// unquoted local part by RFC 5322
+const atextSpecials = "!#$%&'*+-/=?^_`{|}~"
+
+// ValidateAddress checks that address is a plain addr-spec of RFC 5322, such as
+// jane@example.com, without display name, comment or angle brackets. The local
+// part may be quoted and both parts may hold UTF-8 (RFC 6532). The domain must
+// be a host name or an address literal such as [192.0.2.1].
+// The error describes what is wrong, to follow "email".
+func ValidateAddress(address string) error {
+	switch {
+	case address == "":
+		return errors.New("cannot be empty")
+	case !utf8.ValidString(address):
+		return errors.New("must be valid UTF-8")
+	case strings.ContainsAny(address, "<>"):
+		if _, email, ok := trailer.ParseIdent(address); ok {
+			return fmt.Errorf("must be a plain address without name and angle brackets, use %s", email)
+		}
+		return errors.New("must be a plain address without angle brackets")
+	case strings.IndexFunc(address, isSpaceOrControl) >= 0:
+		return errors.New("must not contain whitespace or control characters")
+	}
+
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return errors.New("must contain '@' character")
+	}
+	local, domain := address[:at], address[at+1:]
+
+	if local == "" {
+		return errors.New("has nothing before '@'")
+	}
+	if domain == "" {
+		return errors.New("has no domain after '@'")
+	}
+	if err := validateLocalPart(local); err != nil {
+		return err
+	}
+	return validateDomain(domain)
+}
+
+// validateLocalPart checks the part of an address before the '@'
+func validateLocalPart(local string) error {
+	if len(local) > 64 {
+		return fmt.Errorf("has a local part longer than 64 bytes")
+	}
+
+	if strings.HasPrefix(local, `"`) {
+		if len(local) < 2 || !strings.HasSuffix(local, `"`) {
+			return fmt.Errorf("has an unterminated quoted local part %s", local)
+		}
+		quoted := local[1 : len(local)-1]
+		for i := 0; i < len(quoted); i++ {
+			switch quoted[i] {
+			case '\\':
+				i++ // Quoted pair
+				if i == len(quoted) {
+					return fmt.Errorf("has an unterminated quoted local part %s", local)
+				}
+			case '"':
+				return fmt.Errorf("has an unescaped '\"' in the local part %s", local)
+			}
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return fmt.Errorf("has a local part '%s' that starts or ends with '.' or contains '..'", local)
+	}
+	for _, r := range local {
+		if r != '.' && !isAtext(r) {
+			return fmt.Errorf("has the invalid character %q in the local part '%s'", r, local)
+		}
+	}
+	return nil
+}
+
+// validateDomain checks the part of an address after the '@'
+func validateDomain(domain string) error {
+	if strings.HasPrefix(domain, "[") {
+		if !strings.HasSuffix(domain, "]") || strings.ContainsAny(domain[1:len(domain)-1], `[]\`) {
+			return fmt.Errorf("has an invalid address literal '%s' as domain", domain)
+		}
+		return nil
+	}
+
+	if len(domain) > 253 {
+		return fmt.Errorf("has a domain longer than 253 bytes")
+	}
+	for _, label := range strings.Split(domain, ".") {
+		switch {
+		case label == "":
+			return fmt.Errorf("has a domain '%s' that starts or ends with '.' or contains '..'", domain)
+		case len(label) > 63:
+			return fmt.Errorf("has a domain label '%s' longer than 63 bytes", label)
+		case strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-"):
+			return fmt.Errorf("has a domain label '%s' that starts or ends with '-'", label)
+		}
+		for _, r := range label {
+			if r != '-' && !isLetterOrDigit(r) {
+				return fmt.Errorf("has the invalid character %q in the domain '%s'", r, domain)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateName checks that name can be written into a trailer: it must not be
+// blank or hold line breaks, other control characters or angle brackets. The
+// error suggests the sanitized name. See CheckNameWhitespace for the rest.
+func ValidateName(name string) error {
+	sanitized := trailer.SanitizeName(name)
+
+	switch {
+	case sanitized == "":
+		return errors.New("name cannot be empty")
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return fmt.Errorf("name must not contain line breaks or other control characters, use %q", sanitized)
+	case strings.ContainsAny(name, "<>"):
+		return fmt.Errorf("name must not contain '<' or '>', use %q", sanitized)
+	}
+	return nil
+}
+
+// CheckNameWhitespace reports whitespace in a name that trailers leave out:
+// leading or trailing whitespace and runs of whitespace. Unlike the problems
+// found by ValidateName, it does not keep the name from being used.
+func CheckNameWhitespace(name string) error {
+	sanitized := trailer.SanitizeName(name)
+
+	switch {
+	case name != strings.TrimSpace(name):
+		return fmt.Errorf("name should not start or end with whitespace, trailers use %q", sanitized)
+	case name != sanitized:
+		return fmt.Errorf("name should separate words with single spaces, trailers use %q", sanitized)
+	}
+	return nil
+}
+
+// isAtext reports whether r may appear in an unquoted local part
+func isAtext(r rune) bool {
+	return isLetterOrDigit(r) || strings.ContainsRune(atextSpecials, r)
+}
+
+// isLetterOrDigit accepts ASCII letters and digits as well as any non-ASCII
+// character, which RFC 6532 allows in addresses
+func isLetterOrDigit(r rune) bool {
+	return r >= utf8.RuneSelf || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+func isSpaceOrControl(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAddress(t *testing.T) {
+	valid := []string{
+		"jane@example.com",
+		"jane.doe+pair@mail.example.co.uk",
+		"o'brien@example.com",
+		`"jane,doe"@example.com`,
+		`"jane\"doe"@example.com`,
+		"jane@[192.0.2.1]",
+		"jane@localhost",
+		"jürgen@bücher.example",
+		"49+jane@users.noreply.github.com",
+	}
+	for _, address := range valid {
+		assert.NoError(t, ValidateAddress(address), address)
+	}
+
+	tests := map[string]string{
+		"":                           "cannot be empty",
+		"jane\xff@example.com":       "must be valid UTF-8",
+		"Jane <jane@example.com>":    "must be a plain address without name and angle brackets, use jane@example.com",
+		"<jane":                      "must be a plain address without angle brackets",
+		"jane doe@example.com":       "must not contain whitespace or control characters",
+		"jane@example.com\n":         "must not contain whitespace or control characters",
+		"jane":                       "must contain '@' character",
+		"@example.com":               "has nothing before '@'",
+		"jane@":                      "has no domain after '@'",
+		"jane@@example.com":          "has the invalid character '@' in the local part 'jane@'",
+		".jane@example.com":          "has a local part '.jane' that starts or ends with '.' or contains '..'",
+		"jane..doe@example.com":      "has a local part 'jane..doe' that starts or ends with '.' or contains '..'",
+		"jane(x)@example.com":        "has the invalid character '(' in the local part 'jane(x)'",
+		`"jane@example.com`:          `has an unterminated quoted local part "jane`,
+		`"ja"ne"@example.com`:        `has an unescaped '"' in the local part "ja"ne"`,
+		"jane@example..com":          "has a domain 'example..com' that starts or ends with '.' or contains '..'",
+		"jane@-example.com":          "has a domain label '-example' that starts or ends with '-'",
+		"jane@exa_mple.com":          "has the invalid character '_' in the domain 'exa_mple.com'",
+		"jane@[192.0.2.1":            "has an invalid address literal '[192.0.2.1' as domain",
+		"jane@example.com,john@x.io": "has the invalid character '@' in the local part 'jane@example.com,john'",
+	}
+	for address, expectErr := range tests {
+		assert.EqualError(t, ValidateAddress(address), expectErr, address)
+	}
+
+	long := make([]byte, 65)
+	for i := range long {
+		long[i] = 'a'
+	}
+	assert.EqualError(t, ValidateAddress(string(long)+"@example.com"), "has a local part longer than 64 bytes")
+	assert.EqualError(t, ValidateAddress("jane@"+string(long)+".com"), "has a domain label '"+string(long)+"' longer than 63 bytes")
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"Jane Doe", "J", "Jürgen Müller", "O'Brien, Jane (QA)"} {
+		assert.NoError(t, ValidateName(name), name)
+	}
+
+	tests := map[string]string{
+		"":           "name cannot be empty",
+		" \t ":       "name cannot be empty",
+		"Jane\nDoe":  `name must not contain line breaks or other control characters, use "Jane Doe"`,
+		"Jane <Doe>": `name must not contain '<' or '>', use "Jane Doe"`,
+	}
+	for name, expectErr := range tests {
+		assert.EqualError(t, ValidateName(name), expectErr, name)
+	}
+}
+
+func TestCheckNameWhitespace(t *testing.T) {
+	assert.NoError(t, CheckNameWhitespace("Jane Doe"))
+
+	tests := map[string]string{
+		" Jane Doe":     `name should not start or end with whitespace, trailers use "Jane Doe"`,
+		"Jane  Doe":     `name should separate words with single spaces, trailers use "Jane Doe"`,
+		"Jane\u00a0Doe": `name should separate words with single spaces, trailers use "Jane Doe"`,
+	}
+	for name, expectErr := range tests {
+		assert.NoError(t, ValidateName(name), name)
+		assert.EqualError(t, CheckNameWhitespace(name), expectErr, name)
+	}
+
+	author := CoAuthor{Name: "Jane  Doe", Email: "jane@example.com"}
+	require.Len(t, author.FieldErrors(), 1)
+	assert.True(t, author.FieldErrors()[0].Warning)
+	assert.NoError(t, author.Validate())
+}
+
+func TestCoAuthorFieldErrors(t *testing.T) {
+	author := CoAuthor{
+		Name:  "Jane\tDoe",
+		Email: "jane@example.com",
+		Emails: []Email{
+			{Address: "jane@old.example.com"},
+			{Address: "jane", Hosts: []string{"[github.com"}, Paths: []string{""}},
+		},
+	}
+
+	fields := map[string]string{}
+	for _, problem := range author.FieldErrors() {
+		fields[problem.Field] = problem.Error()
+	}
+	assert.Equal(t, map[string]string{
+		"name":             `name must not contain line breaks or other control characters, use "Jane Doe"`,
+		"emails.1.email":   "alternate email 'jane' must contain '@' character",
+		"emails.1.hosts.0": "invalid host '[github.com' for email 'jane'",
+		"emails.1.paths.0": "invalid path '' for email 'jane'",
+	}, fields)
+
+	assert.EqualError(t, author.Validate(), fields["name"])
+
+	author.Name = "Jane Doe"
+	author.Emails = nil
+	assert.NoError(t, author.Validate())
+}
@@ -3,34 +3,106 @@ package models
 import (
 	"fmt"
 	"strings"
+
+	"github.com/philippeckel/pair/internal/trailer"
 )
 
+// DefaultTrailerKey is the trailer crediting a co-author of a commit
+const DefaultTrailerKey = "Co-authored-by"
+
+// BuiltinTrailerKeys are the trailers pair knows without configuration
+var BuiltinTrailerKeys = []string{DefaultTrailerKey, "Signed-off-by", "Reviewed-by", "Helped-by", "Pair-programmed-with"}
+
 // CoAuthor represents a contributor that can be added to commits
 type CoAuthor struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Alias string `json:"-"` // Not in JSON, filled from the map key
+	Name       string  `json:"name"`
+	Email      string  `json:"email"`
+	Emails     []Email `json:"emails,omitempty"` // Further emails of the same person
+	Alias      string  `json:"-"`                // Not in JSON, filled from the map key
+	TrailerKey string  `json:"-"`                // Trailer crediting an active co-author, DefaultTrailerKey if empty
 }
 
-// Validate checks if the CoAuthor has valid fields
+// Validate checks if the CoAuthor has valid fields, returning the first problem
+// that is not a warning
 func (c *CoAuthor) Validate() error {
-	if c.Name == "" {
-		return fmt.Errorf("name cannot be empty")
+	for _, problem := range c.FieldErrors() {
+		if !problem.Warning {
+			return problem
+		}
 	}
+	return nil
+}
+
+// FieldErrors checks every field of the CoAuthor and returns all problems found:
+// the name must be fit for a trailer and all emails must be plain addresses.
+// Whitespace that trailers leave out of the name is only a warning.
+func (c *CoAuthor) FieldErrors() []*FieldError {
+	var problems []*FieldError
 
-	if c.Email == "" {
-		return fmt.Errorf("email cannot be empty")
+	if err := ValidateName(c.Name); err != nil {
+		problems = append(problems, &FieldError{Field: "name", Err: err})
+	} else if err := CheckNameWhitespace(c.Name); err != nil {
+		problems = append(problems, &FieldError{Field: "name", Err: err, Warning: true})
+	}
+	if err := ValidateAddress(c.Email); err != nil {
+		problems = append(problems, &FieldError{Field: "email", Err: fmt.Errorf("email %w", err)})
 	}
 
-	if !strings.Contains(c.Email, "@") {
-		return fmt.Errorf("email must contain '@' character")
+	for i, email := range c.Emails {
+		for _, problem := range email.FieldErrors() {
+			problem.Field = fmt.Sprintf("emails.%d.%s", i, problem.Field)
+			problems = append(problems, problem)
+		}
 	}
 
-	return nil
+	return problems
+}
+
+// AllEmails returns the primary email followed by the further ones
+func (c *CoAuthor) AllEmails() []string {
+	emails := []string{c.Email}
+	for _, email := range c.Emails {
+		emails = append(emails, email.Address)
+	}
+	return emails
+}
+
+// EmailFor returns the email to credit the co-author with in the repository:
+// the first further email whose rules match, or the primary email
+func (c *CoAuthor) EmailFor(repo Repository, homeDir string) string {
+	for _, email := range c.Emails {
+		if email.Matches(repo, homeDir) {
+			return email.Address
+		}
+	}
+	return c.Email
+}
+
+// Key returns the trailer key crediting the co-author
+func (c *CoAuthor) Key() string {
+	if c.TrailerKey == "" {
+		return DefaultTrailerKey
+	}
+	return c.TrailerKey
+}
+
+// IsCoAuthor reports whether the co-author is credited with Co-authored-by
+// rather than another trailer such as Reviewed-by
+func (c *CoAuthor) IsCoAuthor() bool {
+	return strings.EqualFold(c.Key(), DefaultTrailerKey)
+}
+
+// Trailer returns the trailer crediting the co-author, e.g. "Co-authored-by: Jane <jane@example.com>".
+// Name and email are sanitized, so the trailer is always a single line.
+func (c *CoAuthor) Trailer() string {
+	return trailer.Format(c.Key(), trailer.Ident(c.Name, c.Email))
 }
 
 // Config holds all available co-authors
 type Config struct {
 	CoAuthorsMap map[string]CoAuthor `json:"coauthors"`
 	CoAuthors    []CoAuthor          `json:"-"` // This will be filled after loading
+	Groups       map[string][]string `json:"groups"`
+	GroupNames   []string            `json:"-"` // Group names in declaration order
+	Trailers     []string            `json:"-"` // Custom trailer keys besides BuiltinTrailerKeys
 }
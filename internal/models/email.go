@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Email is a further address of a co-author. An address without rules is only
+// used to recognize the co-author. An address with rules goes into the trailer
+// in repositories matching any of them.
+type Email struct {
+	Address string   `json:"email" yaml:"email"`
+	Hosts   []string `json:"hosts,omitempty" yaml:"hosts,omitempty"` // Hosts of remote URLs, e.g. github.com or *.example.com
+	Paths   []string `json:"paths,omitempty" yaml:"paths,omitempty"` // Globs matched against the repository path and its parents
+}
+
+// Repository describes where a commit is made, to select the email for the trailer
+type Repository struct {
+	Path  string   // Top-level directory
+	Hosts []string // Hosts of all remote URLs
+}
+
+// UnmarshalJSON accepts a plain address as well as an object with rules
+func (e *Email) UnmarshalJSON(data []byte) error {
+	var address string
+	if err := json.Unmarshal(data, &address); err == nil {
+		*e = Email{Address: address}
+		return nil
+	}
+
+	type plain Email
+	var email plain
+	if err := json.Unmarshal(data, &email); err != nil {
+		return fmt.Errorf("email must be an address or an object with \"email\", \"hosts\" and \"paths\": %w", err)
+	}
+	*e = Email(email)
+	return nil
+}
+
+// MarshalJSON writes an address without rules as plain string
+func (e Email) MarshalJSON() ([]byte, error) {
+	if !e.HasRules() {
+		return json.Marshal(e.Address)
+	}
+
+	type plain Email
+	return json.Marshal(plain(e))
+}
+
+// HasRules reports whether the address is selected for some repositories
+func (e *Email) HasRules() bool {
+	return len(e.Hosts) > 0 || len(e.Paths) > 0
+}
+
+// Validate checks the address and the syntax of the rules, returning the first problem
+func (e *Email) Validate() error {
+	if problems := e.FieldErrors(); len(problems) > 0 {
+		return problems[0]
+	}
+	return nil
+}
+
+// FieldErrors checks the address and every rule and returns all problems found
+func (e *Email) FieldErrors() []*FieldError {
+	var problems []*FieldError
+
+	if err := ValidateAddress(e.Address); err != nil {
+		problems = append(problems, &FieldError{Field: "email", Err: fmt.Errorf("alternate email '%s' %w", e.Address, err)})
+	}
+
+	for i, host := range e.Hosts {
+		if _, err := path.Match(strings.ToLower(host), ""); err != nil || host == "" {
+			problems = append(problems, &FieldError{Field: fmt.Sprintf("hosts.%d", i), Err: fmt.Errorf("invalid host '%s' for email '%s'", host, e.Address)})
+		}
+	}
+
+	for i, glob := range e.Paths {
+		if _, err := filepath.Match(glob, ""); err != nil || glob == "" {
+			problems = append(problems, &FieldError{Field: fmt.Sprintf("paths.%d", i), Err: fmt.Errorf("invalid path '%s' for email '%s'", glob, e.Address)})
+		}
+	}
+
+	return problems
+}
+
+// Matches reports whether the rules of the address select it for the repository
+func (e *Email) Matches(repo Repository, homeDir string) bool {
+	for _, pattern := range e.Hosts {
+		for _, host := range repo.Hosts {
+			if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(host)); ok {
+				return true
+			}
+		}
+	}
+
+	if repo.Path == "" {
+		return false
+	}
+
+	for _, glob := range e.Paths {
+		if strings.HasPrefix(glob, "~/") && homeDir != "" {
+			glob = filepath.Join(homeDir, glob[2:])
+		}
+		glob = filepath.Clean(glob)
+
+		// A glob matching a parent directory matches everything below it
+		for dir := filepath.Clean(repo.Path); ; dir = filepath.Dir(dir) {
+			if ok, _ := filepath.Match(glob, dir); ok {
+				return true
+			}
+			if parent := filepath.Dir(dir); parent == dir {
+				break
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailJSON(t *testing.T) {
+	var emails []Email
+	data := `["jane@old.example.com", {"email": "jane@users.noreply.github.com", "hosts": ["github.com"]}]`
+	require.NoError(t, json.Unmarshal([]byte(data), &emails))
+
+	assert.Equal(t, []Email{
+		{Address: "jane@old.example.com"},
+		{Address: "jane@users.noreply.github.com", Hosts: []string{"github.com"}},
+	}, emails)
+
+	encoded, err := json.Marshal(emails)
+	require.NoError(t, err)
+	assert.JSONEq(t, data, string(encoded))
+
+	assert.Error(t, json.Unmarshal([]byte(`[42]`), &emails))
+}
+
+func TestEmailFor(t *testing.T) {
+	jane := CoAuthor{
+		Name:  "Jane Doe",
+		Email: "jane@company.example.com",
+		Emails: []Email{
+			{Address: "jane@old.example.com"},
+			{Address: "jane@users.noreply.github.com", Hosts: []string{"github.com", "*.gitlab.io"}},
+			{Address: "jane@personal.example.org", Paths: []string{"~/oss", "/src/*/contrib"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		repo Repository
+		want string
+	}{
+		{"no repository", Repository{}, "jane@company.example.com"},
+		{"company remote", Repository{Path: "/src/app", Hosts: []string{"git.company.example.com"}}, "jane@company.example.com"},
+		{"host", Repository{Path: "/src/app", Hosts: []string{"GitHub.com"}}, "jane@users.noreply.github.com"},
+		{"host glob", Repository{Hosts: []string{"pages.gitlab.io"}}, "jane@users.noreply.github.com"},
+		{"path below home", Repository{Path: "/home/jane/oss/tool"}, "jane@personal.example.org"},
+		{"path glob", Repository{Path: "/src/lib/contrib/sub"}, "jane@personal.example.org"},
+		{"path prefix only", Repository{Path: "/home/jane/ossified"}, "jane@company.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, jane.EmailFor(tt.repo, "/home/jane"))
+		})
+	}
+
+	assert.NoError(t, jane.Validate())
+	jane.Emails = append(jane.Emails, Email{Address: "jane@example.com", Paths: []string{"[oss"}})
+	assert.ErrorContains(t, jane.Validate(), "invalid path '[oss'")
+}
@@ -0,0 +1,89 @@
+package stats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/philippeckel/pair/internal/git"
+	"github.com/philippeckel/pair/internal/gittemplate"
+	"github.com/philippeckel/pair/internal/models"
+)
+
+// Commit is a commit with everyone who worked on it
+type Commit struct {
+	Hash      string
+	Date      time.Time
+	Author    models.CoAuthor
+	CoAuthors []models.CoAuthor
+}
+
+// Participants returns the author and the co-authors of the commit, each email once
+func (c Commit) Participants() []models.CoAuthor {
+	seen := map[string]bool{}
+	var participants []models.CoAuthor
+	for _, person := range append([]models.CoAuthor{c.Author}, c.CoAuthors...) {
+		key := strings.ToLower(person.Email)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		participants = append(participants, person)
+	}
+	return participants
+}
+
+// Window limits the commits read from the log. The bounds are passed to git as is,
+// so anything "git log --since" understands works, e.g. "2 weeks ago".
+type Window struct {
+	Since string
+	Until string
+}
+
+// logFormat separates the fields of a commit with NUL and commits with RS,
+// and maps the author through .mailmap
+const logFormat = "%H%x00%aI%x00%aN%x00%aE%x00%B%x1e"
+
+// Log reads the commits reachable from HEAD within the window, newest first.
+// The known trailer keys, see config.TrailerKeys, help to find the trailers.
+func Log(window Window, keys []string) ([]Commit, error) {
+	args := []string{"log", "--no-merges", "--format=" + logFormat}
+	if window.Since != "" {
+		args = append(args, "--since="+window.Since)
+	}
+	if window.Until != "" {
+		args = append(args, "--until="+window.Until)
+	}
+
+	output, err := git.Run(args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not read git log: %w", err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, "\x00", 5)
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected git log output: %q", record)
+		}
+
+		date, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of commit %s: %w", fields[0], err)
+		}
+
+		commits = append(commits, Commit{
+			Hash:      fields[0],
+			Date:      date,
+			Author:    models.CoAuthor{Name: fields[2], Email: fields[3]},
+			CoAuthors: gittemplate.ParseMessageCoAuthors(fields[4], keys),
+		})
+	}
+
+	return commits, nil
+}
@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Person sums up the commits of one person, identified by email
+type Person struct {
+	Name          string
+	Email         string
+	Commits       int       // Commits authored or co-authored
+	PairedCommits int       // Commits with at least one other person
+	LastPaired    time.Time // Zero if the person never paired
+}
+
+// Pair sums up the commits two people made together
+type Pair struct {
+	A, B       Person
+	Commits    int
+	LastPaired time.Time
+}
+
+// Report is the pairing statistics of a list of commits
+type Report struct {
+	Commits       int
+	PairedCommits int
+	People        []Person // Most commits first
+	Pairs         []Pair   // Most frequent pair first
+
+	pairIndex map[[2]string]int // Index into Pairs by pairKey
+}
+
+// pairKey returns the key of two people regardless of their order
+func pairKey(emailA, emailB string) [2]string {
+	key := [2]string{strings.ToLower(emailA), strings.ToLower(emailB)}
+	if key[0] > key[1] {
+		key[0], key[1] = key[1], key[0]
+	}
+	return key
+}
+
+// Pair returns how often the people with the two emails paired. Emails are
+// compared case-insensitively and false is returned if they never paired.
+func (r *Report) Pair(emailA, emailB string) (Pair, bool) {
+	index, ok := r.pairIndex[pairKey(emailA, emailB)]
+	if !ok {
+		return Pair{}, false
+	}
+	return r.Pairs[index], true
+}
+
+// Build computes the statistics of the commits. Everyone who worked on a commit
+// is counted as having paired with everyone else on it, so a mob commit counts
+// for every pair in the mob.
+func Build(commits []Commit) *Report {
+	report := &Report{Commits: len(commits), pairIndex: map[[2]string]int{}}
+	people := map[string]*Person{}
+	pairs := map[[2]string]*Pair{}
+
+	for _, commit := range commits {
+		participants := commit.Participants()
+		paired := len(participants) > 1
+		if paired {
+			report.PairedCommits++
+		}
+
+		keys := make([]string, len(participants))
+		for i, participant := range participants {
+			key := strings.ToLower(participant.Email)
+			keys[i] = key
+
+			person, ok := people[key]
+			if !ok {
+				// The log is newest first, so the most recent name is kept
+				person = &Person{Name: participant.Name, Email: participant.Email}
+				people[key] = person
+			}
+			person.Commits++
+			if paired {
+				person.PairedCommits++
+				if commit.Date.After(person.LastPaired) {
+					person.LastPaired = commit.Date
+				}
+			}
+		}
+
+		for i := range keys {
+			for j := i + 1; j < len(keys); j++ {
+				key := pairKey(keys[i], keys[j])
+
+				pair, ok := pairs[key]
+				if !ok {
+					pair = &Pair{}
+					pairs[key] = pair
+				}
+				pair.Commits++
+				if commit.Date.After(pair.LastPaired) {
+					pair.LastPaired = commit.Date
+				}
+			}
+		}
+	}
+
+	for _, person := range people {
+		report.People = append(report.People, *person)
+	}
+	sort.Slice(report.People, func(i, j int) bool {
+		a, b := report.People[i], report.People[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		return a.Name < b.Name
+	})
+
+	for key, pair := range pairs {
+		pair.A, pair.B = *people[key[0]], *people[key[1]]
+		if pair.A.Name > pair.B.Name {
+			pair.A, pair.B = pair.B, pair.A
+		}
+		report.Pairs = append(report.Pairs, *pair)
+	}
+	sort.Slice(report.Pairs, func(i, j int) bool {
+		a, b := report.Pairs[i], report.Pairs[j]
+		if a.Commits != b.Commits {
+			return a.Commits > b.Commits
+		}
+		if !a.LastPaired.Equal(b.LastPaired) {
+			return a.LastPaired.After(b.LastPaired)
+		}
+		return a.A.Name+a.B.Name < b.A.Name+b.B.Name
+	})
+	for i, pair := range report.Pairs {
+		report.pairIndex[pairKey(pair.A.Email, pair.B.Email)] = i
+	}
+
+	return report
+}
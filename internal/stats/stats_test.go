@@ -0,0 +1,52 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/philippeckel/pair/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	jane := models.CoAuthor{Name: "Jane Doe", Email: "jane@example.com"}
+	john := models.CoAuthor{Name: "John Doe", Email: "john@example.com"}
+	sam := models.CoAuthor{Name: "Sam Smith", Email: "sam@example.com"}
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	// Newest first, as read from the log
+	report := Build([]Commit{
+		{Hash: "e", Date: day(5), Author: jane},
+		{Hash: "d", Date: day(4), Author: jane, CoAuthors: []models.CoAuthor{john, sam}},
+		{Hash: "c", Date: day(3), Author: jane, CoAuthors: []models.CoAuthor{{Name: "John", Email: "JOHN@example.com"}}},
+		{Hash: "b", Date: day(2), Author: john, CoAuthors: []models.CoAuthor{jane, jane}},
+		{Hash: "a", Date: day(1), Author: sam},
+	})
+
+	assert.Equal(t, 5, report.Commits)
+	assert.Equal(t, 3, report.PairedCommits)
+
+	require.Len(t, report.People, 3)
+	assert.Equal(t, Person{Name: "Jane Doe", Email: "jane@example.com", Commits: 4, PairedCommits: 3, LastPaired: day(4)}, report.People[0])
+	assert.Equal(t, Person{Name: "John Doe", Email: "john@example.com", Commits: 3, PairedCommits: 3, LastPaired: day(4)}, report.People[1])
+	assert.Equal(t, Person{Name: "Sam Smith", Email: "sam@example.com", Commits: 2, PairedCommits: 1, LastPaired: day(4)}, report.People[2])
+
+	require.Len(t, report.Pairs, 3)
+	assert.Equal(t, "jane@example.com", report.Pairs[0].A.Email)
+	assert.Equal(t, "john@example.com", report.Pairs[0].B.Email)
+	assert.Equal(t, 3, report.Pairs[0].Commits)
+	assert.Equal(t, day(4), report.Pairs[0].LastPaired)
+
+	for _, pair := range report.Pairs[1:] {
+		assert.Equal(t, 1, pair.Commits)
+		assert.Equal(t, "sam@example.com", pair.B.Email)
+	}
+
+	pair, ok := report.Pair("SAM@example.com", "john@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, 1, pair.Commits)
+
+	_, ok = report.Pair("jane@example.com", "nobody@example.com")
+	assert.False(t, ok)
+}
@@ -0,0 +1,291 @@
+// Package trailer parses and formats commit message trailers following the
+// rules of git interpret-trailers: keys are matched case-insensitively and may
+// be followed by whitespace before the separator, values may be folded over
+// continuation lines starting with whitespace, and comment lines are ignored.
+package trailer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Separators are the characters that may separate the key of a trailer from its
+// value, like git's trailer.separators. The first one is used when formatting.
+var Separators = ":"
+
+// commentChar starts the comment lines git strips from commit messages
+const commentChar = "#"
+
+// scissors cuts off the rest of a message, as written by git commit --verbose
+const scissors = "------------------------ >8 ------------------------"
+
+// gitPrefixes are the lines git itself writes into trailer blocks. A block
+// containing one of them may have up to 75% other lines, like in git.
+var gitPrefixes = []string{"Signed-off-by: ", "(cherry picked from commit "}
+
+// Trailer is a single "Key: value" line of a commit message
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// String formats the trailer on a single line, e.g. "Reviewed-by: Jane <jane@example.com>"
+func (t Trailer) String() string {
+	return Format(t.Key, t.Value)
+}
+
+// Format returns the trailer line for the key and value. Line breaks in the
+// value are replaced by spaces, so a value can never add lines to a message.
+func Format(key, value string) string {
+	separator, _ := utf8.DecodeRuneInString(Separators)
+	return key + string(separator) + " " + singleLine(value)
+}
+
+// Ident formats a person as "Name <email>" for a trailer value, sanitizing both
+// so the value always parses back into the same name and email
+func Ident(name, email string) string {
+	return SanitizeName(name) + " <" + SanitizeEmail(email) + ">"
+}
+
+// SanitizeName removes angle brackets from a name and replaces line breaks and
+// other control characters by spaces, collapsing runs of whitespace
+func SanitizeName(name string) string {
+	return singleLine(strings.Map(func(r rune) rune {
+		if r == '<' || r == '>' {
+			return -1
+		}
+		return r
+	}, name))
+}
+
+// SanitizeEmail removes angle brackets, whitespace and control characters from
+// an email address
+func SanitizeEmail(email string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '<' || r == '>' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, email)
+}
+
+// ParseIdent splits a trailer value of the format "Name <email>". The email is
+// taken from the last pair of angle brackets, so names may contain brackets
+// themselves, and anything after the closing bracket is a comment and ignored.
+func ParseIdent(value string) (name, email string, ok bool) {
+	end := strings.LastIndex(value, ">")
+	if end < 0 {
+		return "", "", false
+	}
+	start := strings.LastIndex(value[:end], "<")
+	if start < 0 {
+		return "", "", false
+	}
+
+	name = strings.TrimSpace(value[:start])
+	email = strings.TrimSpace(value[start+1 : end])
+	if email == "" {
+		return "", "", false
+	}
+	return name, email, true
+}
+
+// Parse returns the trailers of a commit message. Like git, only the last
+// paragraph of the message is considered, and only if it is made of trailers,
+// or at least a quarter of it is and it holds a trailer written by git or one of
+// the known keys. The subject is never a trailer block.
+func Parse(message string, known []string) []Trailer {
+	lines := messageLines(message)
+
+	// The subject is the first paragraph and may not hold trailers
+	first := 0
+	for first < len(lines) && (strings.HasPrefix(lines[first], commentChar) || !isBlank(lines[first])) {
+		first++
+	}
+
+	start, ok := blockStart(lines[first:], known)
+	if !ok {
+		return nil
+	}
+	return ParseLines(lines[first+start:])
+}
+
+// ParseLines returns the trailers of lines that are all part of a trailer block.
+// Continuation lines are unfolded into the value of the trailer before them, and
+// comment and other lines are skipped.
+func ParseLines(lines []string) []Trailer {
+	var trailers []Trailer
+	continues := false
+
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(line, commentChar):
+			continue
+		case isContinuation(line):
+			if continues {
+				last := &trailers[len(trailers)-1]
+				last.Value = strings.TrimSpace(last.Value + " " + strings.TrimSpace(line))
+			}
+			continue
+		}
+
+		trailer, ok := parseLine(line)
+		continues = ok
+		if ok {
+			trailers = append(trailers, trailer)
+		}
+	}
+
+	return trailers
+}
+
+// parseLine splits a single "Key: value" line. The key holds letters, digits and
+// dashes, and may be followed by whitespace before the separator.
+func parseLine(line string) (Trailer, bool) {
+	pos := separatorIndex(line)
+	if pos < 1 {
+		return Trailer{}, false
+	}
+
+	key := strings.TrimRight(line[:pos], " \t")
+	value := strings.TrimSpace(line[pos+1:])
+	return Trailer{Key: key, Value: value}, true
+}
+
+// separatorIndex returns the byte position of the separator of a trailer line,
+// or -1 if the line is not a trailer, like find_separator in git
+func separatorIndex(line string) int {
+	whitespace := false
+	for i, r := range line {
+		if strings.ContainsRune(Separators, r) {
+			return i
+		}
+		if !whitespace && isKeyRune(r) {
+			continue
+		}
+		if i > 0 && (r == ' ' || r == '\t') {
+			whitespace = true
+			continue
+		}
+		break
+	}
+	return -1
+}
+
+// isKeyRune reports whether r may appear in a trailer key. Like git, only ASCII
+// letters, digits and dashes are accepted.
+func isKeyRune(r rune) bool {
+	return r == '-' || (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+// blockStart returns the index of the first line of the trailer block at the
+// end of lines, the message after its subject, like find_trailer_block_start
+// in git
+func blockStart(lines []string, known []string) (int, bool) {
+	trailerLines, otherLines, continuations := 0, 0, 0
+	recognized := false
+	onlySpaces := true
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		if strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		if isBlank(line) {
+			if onlySpaces {
+				continue
+			}
+			return i + 1, isBlock(trailerLines, otherLines, recognized)
+		}
+		onlySpaces = false
+
+		if isContinuation(line) {
+			continuations++
+			continue
+		}
+
+		if hasGitPrefix(line) || isKnown(line, known) {
+			trailerLines++
+			trailerLines += continuations
+			continuations = 0
+			recognized = true
+			continue
+		}
+
+		if separatorIndex(line) >= 1 {
+			trailerLines++
+			trailerLines += continuations
+		} else {
+			otherLines++
+			otherLines += continuations
+		}
+		continuations = 0
+	}
+
+	// lines starts with the blank line after the subject, so only a message
+	// without a body gets here
+	return 0, false
+}
+
+// isBlock decides whether a paragraph is a trailer block from its line counts
+func isBlock(trailerLines, otherLines int, recognized bool) bool {
+	if trailerLines > 0 && otherLines == 0 {
+		return true
+	}
+	return recognized && trailerLines*3 >= otherLines
+}
+
+// hasGitPrefix reports whether git writes lines like this one into trailer blocks
+func hasGitPrefix(line string) bool {
+	for _, prefix := range gitPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isKnown reports whether the line is a trailer with one of the known keys
+func isKnown(line string, known []string) bool {
+	trailer, ok := parseLine(line)
+	if !ok {
+		return false
+	}
+	for _, key := range known {
+		if strings.EqualFold(trailer.Key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageLines splits a message into lines, without the part git cuts off at
+// the scissors line
+func messageLines(message string) []string {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, commentChar) && strings.Contains(line, scissors) {
+			return lines[:i]
+		}
+	}
+	return lines
+}
+
+// isContinuation reports whether the line continues the trailer before it
+func isContinuation(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// singleLine replaces line breaks and other control characters by spaces and
+// collapses runs of whitespace
+func singleLine(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+}
@@ -0,0 +1,174 @@
+package trailer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	known := []string{"Co-authored-by"}
+
+	tests := []struct {
+		name    string
+		message string
+		want    []Trailer
+	}{
+		{
+			"trailer block",
+			"Fix login\n\nThe session expired too early.\n\nCo-authored-by: Jane Doe <jane@example.com>\nReviewed-by: John <john@example.com>\n",
+			[]Trailer{{"Co-authored-by", "Jane Doe <jane@example.com>"}, {"Reviewed-by", "John <john@example.com>"}},
+		},
+		{
+			"lowercase key and whitespace before the separator",
+			"Fix login\n\nco-authored-by : Jane <jane@example.com>\n",
+			[]Trailer{{"co-authored-by", "Jane <jane@example.com>"}},
+		},
+		{
+			"continuation lines",
+			"Fix login\n\nCo-authored-by: Jane\n  Doe <jane@example.com>\nReviewed-by: John <john@example.com>\n",
+			[]Trailer{{"Co-authored-by", "Jane Doe <jane@example.com>"}, {"Reviewed-by", "John <john@example.com>"}},
+		},
+		{
+			"comment lines",
+			"Fix login\n\nCo-authored-by: Jane <jane@example.com>\n# Please enter the commit message\n#\n",
+			[]Trailer{{"Co-authored-by", "Jane <jane@example.com>"}},
+		},
+		{
+			"scissors",
+			"Fix login\n\nCo-authored-by: Jane <jane@example.com>\n# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n",
+			[]Trailer{{"Co-authored-by", "Jane <jane@example.com>"}},
+		},
+		{
+			"known key among other lines",
+			"Fix login\n\nSee the issue for details\nCo-authored-by: Jane <jane@example.com>\n",
+			[]Trailer{{"Co-authored-by", "Jane <jane@example.com>"}},
+		},
+		{
+			"unknown key among other lines",
+			"Fix login\n\nSee the issue for details\nNote: not a trailer block\n",
+			nil,
+		},
+		{
+			"not the last paragraph",
+			"Fix login\n\nCo-authored-by: Jane <jane@example.com>\n\nThis paragraph ends the message.\n",
+			nil,
+		},
+		{
+			"subject only",
+			"Co-authored-by: Jane <jane@example.com>\n",
+			nil,
+		},
+		{
+			"empty",
+			"",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.message, known))
+		})
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	lines := []string{
+		"# Expires: 2026-01-01T00:00:00Z",
+		"Co-authored-by: Jane <jane@example.com>",
+		"  (pairing on the parser)",
+		"not a trailer",
+		"  dropped continuation",
+		"Key-without-value:",
+		": no key",
+		"Bad key: value",
+	}
+
+	assert.Equal(t, []Trailer{
+		{"Co-authored-by", "Jane <jane@example.com> (pairing on the parser)"},
+		{"Key-without-value", ""},
+	}, ParseLines(lines))
+}
+
+func TestParseIdent(t *testing.T) {
+	tests := []struct {
+		value string
+		name  string
+		email string
+		ok    bool
+	}{
+		{"Jane Doe <jane@example.com>", "Jane Doe", "jane@example.com", true},
+		{"Jane <Dev> Doe <jane@example.com>", "Jane <Dev> Doe", "jane@example.com", true},
+		{"Jane <jane@example.com> (pairing)", "Jane", "jane@example.com", true},
+		{"<jane@example.com>", "", "jane@example.com", true},
+		{"Jane <>", "", "", false},
+		{"Jane jane@example.com", "", "", false},
+		{"Jane > <", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			name, email, ok := ParseIdent(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.email, email)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "Co-authored-by: Jane Doe <jane@example.com>", Format("Co-authored-by", Ident("Jane Doe", "jane@example.com")))
+	assert.Equal(t, "Co-authored-by: Jane Doe <jane@example.com>", Format("Co-authored-by", Ident("Jane\nDoe", "jane@example.com")))
+	assert.Equal(t, "Reviewed-by: Jane x Doe <jane@example.com>", Format("Reviewed-by", Ident(" Jane <x> Doe\r\n", "<jane@\nexample.com>")))
+	assert.Equal(t, "Co-authored-by: Jane Signed-off-by: Mallory <m@example.com>", Trailer{"Co-authored-by", "Jane\nSigned-off-by: Mallory <m@example.com>"}.String())
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add("Fix login\n\nCo-authored-by: Jane <jane@example.com>\n")
+	f.Add("Fix\n\nco-authored-by : Jane\n Doe <jane@example.com>\n# comment\n")
+	f.Add("Fix\n\ntext\nSigned-off-by: John <john@example.com>\n(cherry picked from commit abc)\n")
+	f.Add("\n\n\t:\n# ------------------------ >8 ------------------------\n")
+
+	f.Fuzz(func(t *testing.T, message string) {
+		for _, trailer := range Parse(message, []string{"Co-authored-by"}) {
+			if trailer.Key == "" || separatorIndex(trailer.Key+":") != len(trailer.Key) {
+				t.Errorf("invalid key %q", trailer.Key)
+			}
+			if strings.Contains(trailer.Value, "\n") {
+				t.Errorf("value %q spans lines", trailer.Value)
+			}
+		}
+	})
+}
+
+func FuzzIdent(f *testing.F) {
+	f.Add("Jane Doe", "jane@example.com")
+	f.Add("Jane <Dev>\nSigned-off-by: Mallory", "<m@example.com>")
+	f.Add("\x00\u2028", " a\tb ")
+
+	f.Fuzz(func(t *testing.T, name, email string) {
+		line := Format("Co-authored-by", Ident(name, email))
+		if strings.ContainsAny(line, "\r\n") {
+			t.Fatalf("trailer %q spans lines", line)
+		}
+
+		trailers := ParseLines(strings.Split(line, "\n"))
+		if len(trailers) != 1 || trailers[0].Key != "Co-authored-by" {
+			t.Fatalf("trailer %q parsed as %v", line, trailers)
+		}
+
+		parsedName, parsedEmail, ok := ParseIdent(trailers[0].Value)
+		if !ok {
+			// Only an email that is empty after sanitizing cannot be parsed
+			if strings.Contains(line, "<>") {
+				return
+			}
+			t.Fatalf("value %q of %q is not an ident", trailers[0].Value, line)
+		}
+		if again := Ident(parsedName, parsedEmail); again != Ident(name, email) {
+			t.Errorf("ident %q parsed back as %q", Ident(name, email), again)
+		}
+	})
+}